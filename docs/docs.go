@@ -84,6 +84,55 @@ const docTemplate = `{
                 }
             }
         },
+        "/damage/sequence": {
+            "post": {
+                "description": "Resolves an ordered list of unit activations against one target, each starting from the wounds the previous ones left. Stops once the target is certain to be wiped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "damage"
+                ],
+                "summary": "Calculate Sequence",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request UUID",
+                        "name": "X-Request-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Activations and target",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/damagerequest.SequenceRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/damagerequest.SequenceResponseDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input payload",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/ready": {
             "get": {
                 "description": "Confirm the server is ready to receive traffic (currently same as alive).",
@@ -106,25 +155,115 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "calculator.RerollType": {
+        "calculator.DiceReroll": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "DiceRerollNone",
+                "DiceRerollBelowAverage",
+                "DiceRerollBelow",
+                "DiceRerollSingle"
+            ]
+        },
+        "calculator.FiringMode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-varnames": [
+                "FiringModeNormal",
+                "FiringModeOverwatch"
+            ]
+        },
+        "calculator.MortalWoundTrigger": {
             "type": "integer",
             "enum": [
                 0,
                 1,
                 2
             ],
+            "x-enum-varnames": [
+                "MortalWoundsNever",
+                "MortalWoundsOnCriticalHit",
+                "MortalWoundsOnCriticalWound"
+            ]
+        },
+        "calculator.RerollType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4
+            ],
             "x-enum-varnames": [
                 "RerollNone",
                 "RerollOnes",
-                "RerollFail"
+                "RerollFail",
+                "RerollNonCritical",
+                "RerollOptimal"
             ]
         },
+        "damagerequest.ActivationDTO": {
+            "type": "object",
+            "properties": {
+                "attacker": {
+                    "$ref": "#/definitions/damagerequest.AttackerDTO"
+                },
+                "rules": {
+                    "$ref": "#/definitions/damagerequest.RulesDTO"
+                },
+                "weapons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.AttackerDTO"
+                    }
+                }
+            }
+        },
+        "damagerequest.AntiDTO": {
+            "type": "object",
+            "properties": {
+                "keyword": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
         "damagerequest.AttackerDTO": {
             "type": "object",
             "properties": {
+                "anti": {
+                    "description": "Anti lists [ANTI-KEYWORD X+] abilities, e.g. Anti-Infantry 4+.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.AntiDTO"
+                    }
+                },
                 "ap": {
                     "type": "integer"
                 },
+                "attacks_options": {
+                    "description": "AttacksOptions change how each roll of attacks_string is made, e.g. \"count results below 3 as 3\".",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/damagerequest.DiceOptionsDTO"
+                        }
+                    ]
+                },
+                "attacks_per_unit": {
+                    "description": "AttacksPerUnit rolls attacks_string once for the whole unit instead of once per model.",
+                    "type": "boolean"
+                },
                 "attacks_string": {
                     "type": "string"
                 },
@@ -135,32 +274,137 @@ const docTemplate = `{
                 "bs": {
                     "type": "integer"
                 },
+                "critical_hit_ap_bonus": {
+                    "description": "CriticalHitAPBonus improves the AP of a Critical Hit, e.g. 1 turns AP -1 into AP -2.",
+                    "type": "integer"
+                },
+                "critical_hit_strength": {
+                    "description": "CriticalHitStrength is the Strength a Critical Hit rolls to wound with (0 keeps s).",
+                    "type": "integer"
+                },
+                "critical_wound_ap_bonus": {
+                    "description": "CriticalWoundAPBonus improves the AP of an attack that scores a Critical Wound.",
+                    "type": "integer"
+                },
+                "critical_wound_damage_bonus": {
+                    "description": "CriticalWoundDamageBonus adds to the Damage of an attack that scores a Critical Wound.",
+                    "type": "integer"
+                },
                 "d": {
                     "type": "string"
                 },
+                "damage_options": {
+                    "description": "DamageOptions change how each roll of d is made, e.g. \"minimum 3\" or \"roll twice, keep the highest\".",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/damagerequest.DiceOptionsDTO"
+                        }
+                    ]
+                },
                 "devastating_wounds": {
                     "type": "boolean"
                 },
+                "hazardous": {
+                    "description": "Hazardous: each model takes a Hazardous test after firing.",
+                    "type": "boolean"
+                },
+                "heavy": {
+                    "description": "Heavy: +1 to hit if the unit remained stationary.",
+                    "type": "boolean"
+                },
                 "hit_modifier": {
                     "type": "integer"
                 },
+                "hit_modifiers": {
+                    "description": "HitModifiers and WoundModifiers are named modifier sources of this\nprofile. With rules.hit_modifiers/wound_modifiers and the weapon\nabilities, they are summed and capped at +1/-1.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.ModifierDTO"
+                    }
+                },
+                "ignore_hit_modifiers": {
+                    "description": "IgnoreHitModifiers/IgnoreWoundModifiers: the profile may ignore any or all modifiers to those rolls.",
+                    "type": "boolean"
+                },
+                "ignore_wound_modifiers": {
+                    "type": "boolean"
+                },
+                "ignores_cover": {
+                    "description": "IgnoresCover: the target never has the Benefit of Cover.",
+                    "type": "boolean"
+                },
+                "indirect_fire": {
+                    "description": "IndirectFire: -1 to hit and the target has cover when it is not visible.",
+                    "type": "boolean"
+                },
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lance": {
+                    "description": "Lance: +1 to wound if the unit charged.",
+                    "type": "boolean"
+                },
+                "legacy_devastating_wounds": {
+                    "description": "LegacyDevastatingWounds: a critical wound inflicts mortal wounds equal to Damage instead.",
+                    "type": "boolean"
+                },
                 "lethal_hits": {
                     "type": "boolean"
                 },
+                "melta": {
+                    "description": "Melta is X of [MELTA X]: +X damage per attack within half range.",
+                    "type": "integer"
+                },
+                "mortal_wounds": {
+                    "description": "MortalWounds inflicted per critical result of mortal_wounds_on, e.g. \"1\" or \"d3\".",
+                    "type": "string"
+                },
+                "mortal_wounds_on": {
+                    "$ref": "#/definitions/calculator.MortalWoundTrigger"
+                },
                 "num_models": {
                     "type": "integer"
                 },
+                "precision": {
+                    "description": "Precision: attacks are allocated to the target's precision_target Character group first.",
+                    "type": "boolean"
+                },
+                "rapid_fire": {
+                    "description": "RapidFire is X of [RAPID FIRE X]: +X attacks per model within half range.",
+                    "type": "integer"
+                },
                 "s": {
                     "type": "integer"
                 },
                 "sustained_hits": {
                     "type": "integer"
                 },
+                "sustained_hits_dice": {
+                    "description": "SustainedHitsDice is a variable Sustained Hits value, e.g. \"d3\", added to sustained_hits.",
+                    "type": "string"
+                },
                 "torrent": {
                     "type": "boolean"
                 },
+                "twin_linked": {
+                    "description": "TwinLinked: re-roll wound rolls.",
+                    "type": "boolean"
+                },
                 "wound_modifier": {
                     "type": "integer"
+                },
+                "wound_modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.ModifierDTO"
+                    }
+                },
+                "wounds_per_model": {
+                    "description": "WoundsPerModel and Keywords describe the attacking models for Hazardous tests,\ne.g. [\"CHARACTER\"] for a model that suffers mortal wounds instead of being destroyed.",
+                    "type": "integer"
                 }
             }
         },
@@ -170,11 +414,34 @@ const docTemplate = `{
                 "attacker": {
                     "$ref": "#/definitions/damagerequest.AttackerDTO"
                 },
+                "points": {
+                    "description": "Points prices the attacking unit and the target's models.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/damagerequest.PointsDTO"
+                        }
+                    ]
+                },
                 "rules": {
                     "$ref": "#/definitions/damagerequest.RulesDTO"
                 },
                 "target": {
                     "$ref": "#/definitions/damagerequest.TargetDTO"
+                },
+                "thresholds": {
+                    "description": "Thresholds are the results whose odds the summary reports.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/damagerequest.ThresholdsDTO"
+                        }
+                    ]
+                },
+                "weapons": {
+                    "description": "Weapons are further profiles fired by the same unit, resolved after attacker.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.AttackerDTO"
+                    }
                 }
             }
         },
@@ -192,6 +459,57 @@ const docTemplate = `{
                 },
                 "summary": {
                     "$ref": "#/definitions/damagerequest.SummaryDTO"
+                },
+                "weapons": {
+                    "description": "Weapons breaks a multi-weapon volley down per profile (attacker first),\neach as if it had fired alone at the undamaged target.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.WeaponResultDTO"
+                    }
+                }
+            }
+        },
+        "damagerequest.DiceOptionsDTO": {
+            "type": "object",
+            "properties": {
+                "best_of": {
+                    "description": "BestOf rolls the dice this many times and keeps the highest result.",
+                    "type": "integer"
+                },
+                "minimum": {
+                    "description": "Minimum is the lowest result of the roll, before other modifiers.",
+                    "type": "integer"
+                },
+                "reroll": {
+                    "description": "Reroll is \"below_average\" (rolls below the dice average), \"below\" (results below reroll_below) or \"single\" (one roll per volley).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calculator.DiceReroll"
+                        }
+                    ]
+                },
+                "reroll_below": {
+                    "type": "integer"
+                }
+            }
+        },
+        "damagerequest.DistributionStatsDTO": {
+            "type": "object",
+            "properties": {
+                "damage": {
+                    "$ref": "#/definitions/damagerequest.StatsDTO"
+                },
+                "hits": {
+                    "$ref": "#/definitions/damagerequest.StatsDTO"
+                },
+                "models_destroyed": {
+                    "$ref": "#/definitions/damagerequest.StatsDTO"
+                },
+                "saves_failed": {
+                    "$ref": "#/definitions/damagerequest.StatsDTO"
+                },
+                "wounds": {
+                    "$ref": "#/definitions/damagerequest.StatsDTO"
                 }
             }
         },
@@ -205,6 +523,13 @@ const docTemplate = `{
                         "format": "float64"
                     }
                 },
+                "groups_destroyed": {
+                    "description": "GroupsDestroyed is models_destroyed per target group, in request order.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.GroupDestroyedDTO"
+                    }
+                },
                 "hits": {
                     "type": "object",
                     "additionalProperties": {
@@ -219,6 +544,30 @@ const docTemplate = `{
                         "format": "float64"
                     }
                 },
+                "mortal_wounds": {
+                    "description": "MortalWounds is the number of mortal wounds inflicted, before FNP.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "points_destroyed": {
+                    "description": "PointsDestroyed is models_destroyed priced at points.target_per_model.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "remaining_wounds": {
+                    "description": "RemainingWounds is the total wounds the target has left; WoundedModel is the wounds left\non the model the next attack would be allocated to (0 once the unit is destroyed).",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
                 "saves_failed": {
                     "type": "object",
                     "additionalProperties": {
@@ -226,18 +575,109 @@ const docTemplate = `{
                         "format": "float64"
                     }
                 },
+                "self_destroyed": {
+                    "description": "SelfDestroyed is the number of attacking models destroyed by their Hazardous tests.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "wounded_model_wounds": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
                 "wounds": {
                     "type": "object",
                     "additionalProperties": {
                         "type": "number",
                         "format": "float64"
                     }
+                },
+                "wounds_lost": {
+                    "description": "WoundsLost is damage without the damage in excess of each model's wounds.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "damagerequest.GroupDestroyedDTO": {
+            "type": "object",
+            "properties": {
+                "models_destroyed": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "damagerequest.ModelGroupDTO": {
+            "type": "object",
+            "properties": {
+                "character": {
+                    "description": "Character groups are allocated attacks after every other model.",
+                    "type": "boolean"
+                },
+                "feel_no_pain": {
+                    "type": "integer"
+                },
+                "invulnerable": {
+                    "type": "integer"
+                },
+                "model_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "save": {
+                    "type": "integer"
+                },
+                "wounds_per_model": {
+                    "type": "integer"
+                }
+            }
+        },
+        "damagerequest.ModifierDTO": {
+            "type": "object",
+            "properties": {
+                "source": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "damagerequest.PointsDTO": {
+            "type": "object",
+            "properties": {
+                "attacker": {
+                    "type": "integer"
+                },
+                "target_per_model": {
+                    "type": "integer"
                 }
             }
         },
         "damagerequest.RulesDTO": {
             "type": "object",
             "properties": {
+                "charged": {
+                    "description": "Charged activates Lance.",
+                    "type": "boolean"
+                },
                 "critical_hit_threshold": {
                     "description": "Thresholds allow for rules like \"Critical hits on a 5+\"",
                     "type": "integer"
@@ -245,20 +685,189 @@ const docTemplate = `{
                 "critical_wound_threshold": {
                     "type": "integer"
                 },
+                "firing_mode": {
+                    "description": "FiringMode is \"normal\" or \"overwatch\": in Overwatch only critical hits hit, unless the weapon has Torrent.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calculator.FiringMode"
+                        }
+                    ]
+                },
+                "half_range": {
+                    "description": "HalfRange activates Melta and Rapid Fire.",
+                    "type": "boolean"
+                },
+                "hit_modifiers": {
+                    "description": "HitModifiers and WoundModifiers are named sources shared by every\nprofile, e.g. auras or the target's Stealth.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.ModifierDTO"
+                    }
+                },
                 "hit_reroll": {
                     "$ref": "#/definitions/calculator.RerollType"
                 },
+                "remained_stationary": {
+                    "description": "RemainedStationary activates Heavy.",
+                    "type": "boolean"
+                },
                 "save_modifier": {
                     "type": "integer"
                 },
                 "save_reroll": {
                     "$ref": "#/definitions/calculator.RerollType"
                 },
+                "single_reroll": {
+                    "description": "SingleReroll allows one die to be rerolled per stage, e.g. a\nCommand Re-roll. It cannot be used with weapons.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/damagerequest.SingleRerollDTO"
+                        }
+                    ]
+                },
+                "target_not_visible": {
+                    "description": "TargetNotVisible activates the Indirect Fire penalties.",
+                    "type": "boolean"
+                },
+                "wound_modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.ModifierDTO"
+                    }
+                },
                 "wound_reroll": {
                     "$ref": "#/definitions/calculator.RerollType"
                 }
             }
         },
+        "damagerequest.SequenceRequestDTO": {
+            "type": "object",
+            "properties": {
+                "activations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.ActivationDTO"
+                    }
+                },
+                "target": {
+                    "$ref": "#/definitions/damagerequest.TargetDTO"
+                }
+            }
+        },
+        "damagerequest.SequenceResponseDTO": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "models_destroyed": {
+                    "description": "Destroyed and WipeProbability are those of the last step.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "request_uuid": {
+                    "type": "string"
+                },
+                "steps": {
+                    "description": "Steps has one entry per activation run. Activations after the target is\ncertain to be wiped are skipped, so it may be shorter than the request.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.SequenceStepDTO"
+                    }
+                },
+                "wipe_probability": {
+                    "type": "number"
+                }
+            }
+        },
+        "damagerequest.SequenceStepDTO": {
+            "type": "object",
+            "properties": {
+                "cumulative_models_destroyed": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "models_destroyed": {
+                    "description": "Destroyed is the models this activation destroyed; CumulativeDestroyed those destroyed since the first.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "remaining_wounds": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "wipe_probability": {
+                    "type": "number"
+                }
+            }
+        },
+        "damagerequest.SingleRerollDTO": {
+            "type": "object",
+            "properties": {
+                "attacks": {
+                    "type": "boolean"
+                },
+                "damage": {
+                    "type": "boolean"
+                },
+                "hit": {
+                    "type": "boolean"
+                },
+                "save": {
+                    "type": "boolean"
+                },
+                "wound": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "damagerequest.StatsDTO": {
+            "type": "object",
+            "properties": {
+                "at_least": {
+                    "description": "AtLeast maps each k to the probability of at least k.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "mean": {
+                    "type": "number"
+                },
+                "median": {
+                    "description": "Median, P10 and P90 are the smallest values reached with 50%, 10% and 90% cumulative probability.",
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "integer"
+                },
+                "p10": {
+                    "type": "integer"
+                },
+                "p90": {
+                    "type": "integer"
+                },
+                "std_dev": {
+                    "type": "number"
+                },
+                "variance": {
+                    "type": "number"
+                }
+            }
+        },
         "damagerequest.SummaryDTO": {
             "type": "object",
             "properties": {
@@ -267,33 +876,179 @@ const docTemplate = `{
                 },
                 "average_hits": {
                     "type": "number"
+                },
+                "character_destroyed": {
+                    "description": "CharacterDestroyed is the probability that the precision_target character group is destroyed.",
+                    "type": "number"
+                },
+                "damage_at_least": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "damage_tail_mass": {
+                    "type": "number"
+                },
+                "effective_hit_modifier": {
+                    "description": "EffectiveHitModifier and EffectiveWoundModifier are the net, capped modifiers the profile rolled with.",
+                    "type": "integer"
+                },
+                "effective_wound_modifier": {
+                    "type": "integer"
+                },
+                "expected_points_destroyed": {
+                    "description": "ExpectedPointsDestroyed is the mean of points_destroyed, and PointsPer100 that mean\nper 100 points of the attacking unit. Zero when the points are not given.",
+                    "type": "number"
+                },
+                "models_destroyed_at_least": {
+                    "description": "DestroyedAtLeast and DamageAtLeast map each requested threshold to the probability of reaching it.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "models_destroyed_tail_mass": {
+                    "description": "DestroyedTailMass and DamageTailMass are the probability dropped by pruning, by which\nthe probabilities above may be low.",
+                    "type": "number"
+                },
+                "points_destroyed_per_100": {
+                    "type": "number"
+                },
+                "stats": {
+                    "description": "Stats summarizes each distribution, keyed like distributions.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/damagerequest.DistributionStatsDTO"
+                        }
+                    ]
+                },
+                "wipe_probability": {
+                    "description": "WipeProbability is the probability that every model of the target is destroyed.",
+                    "type": "number"
                 }
             }
         },
         "damagerequest.TargetDTO": {
             "type": "object",
             "properties": {
+                "ap_reduction": {
+                    "description": "APReduction worsens each attack's AP (min 0), e.g. 1 for Armour of Contempt.",
+                    "type": "integer"
+                },
                 "cover": {
                     "type": "boolean"
                 },
+                "damage_reduction": {
+                    "description": "DamageReduction is subtracted from each attack's Damage (min 1), e.g. 1 for \"-1 Damage\".",
+                    "type": "integer"
+                },
+                "damage_reduction_excludes_devastating": {
+                    "description": "DamageReductionExcludesDevastating stops damage_reduction and halve_damage from applying to devastating wounds.",
+                    "type": "boolean"
+                },
                 "feel_no_pain": {
                     "description": "FNP (e.g., 6)",
                     "type": "integer"
                 },
+                "feel_no_pain_excludes_devastating": {
+                    "description": "FeelNoPainExcludesDevastating stops feel_no_pain from applying to devastating wound damage.",
+                    "type": "boolean"
+                },
+                "groups": {
+                    "description": "Groups describes a unit of mixed models. When set, it replaces\nmodel_count, wounds_per_model, save, invulnerable and feel_no_pain.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/damagerequest.ModelGroupDTO"
+                    }
+                },
+                "halve_damage": {
+                    "description": "HalveDamage halves each attack's Damage, rounding up, before damage_reduction.",
+                    "type": "boolean"
+                },
                 "invulnerable": {
                     "type": "integer"
                 },
+                "keywords": {
+                    "description": "Keywords are matched against the attacker's Anti abilities.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "minus_one_to_wound_if_stronger": {
+                    "description": "MinusOneToWoundIfStronger: -1 to wound rolls of attacks with Strength greater than t.",
+                    "type": "boolean"
+                },
                 "model_count": {
                     "type": "integer"
                 },
+                "mortal_feel_no_pain": {
+                    "description": "MortalFeelNoPain is an FNP usable only against mortal wounds.",
+                    "type": "integer"
+                },
+                "precision_target": {
+                    "description": "PrecisionTarget names the character group precision attacks are allocated to; empty is the first one.",
+                    "type": "string"
+                },
                 "save": {
                     "type": "integer"
                 },
+                "stealth": {
+                    "description": "Stealth: -1 to hit rolls of attacks against the target.",
+                    "type": "boolean"
+                },
                 "t": {
                     "type": "integer"
                 },
+                "wound_rolls_fail_up_to": {
+                    "description": "WoundRollsFailUpTo: unmodified wound rolls up to this value always fail, e.g. 3 for Transhuman.",
+                    "type": "integer"
+                },
                 "wounds_per_model": {
                     "type": "integer"
+                },
+                "wounds_remaining": {
+                    "description": "WoundsRemaining is the wounds the unit has left before the attack, e.g. from an\nearlier remaining_wounds result. Omitted for an undamaged unit.",
+                    "type": "integer"
+                },
+                "wounds_remaining_dist": {
+                    "description": "WoundsRemainingDist replaces wounds_remaining with a distribution of the wounds the\nunit has left, shaped like a remaining_wounds result: 0 is a unit already destroyed.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "damagerequest.ThresholdsDTO": {
+            "type": "object",
+            "properties": {
+                "damage": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "models_destroyed": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "damagerequest.WeaponResultDTO": {
+            "type": "object",
+            "properties": {
+                "distributions": {
+                    "$ref": "#/definitions/damagerequest.DistributionsDTO"
+                },
+                "summary": {
+                    "$ref": "#/definitions/damagerequest.SummaryDTO"
                 }
             }
         }
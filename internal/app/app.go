@@ -107,6 +107,7 @@ func BuildPublicHandler(middlewares ...Middleware) http.Handler {
 func BuildProtectedHandler(calc *calculator.DamageCalculatorImpl, log *zap.Logger, middlewares ...Middleware) http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/damage/calculate", handler.CalculateDamageHandler(calc, log))
+	mux.HandleFunc("/api/damage/sequence", handler.CalculateSequenceHandler(calc, log))
 
 	return Apply(mux, middlewares...)
 }
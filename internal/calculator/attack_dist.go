@@ -20,44 +20,99 @@
 
 package calculator
 
+// CalculateAttackDistribution returns the PMF of the number of attacks the
+// unit makes. opts change how each Attacks roll is made. singleReroll
+// rerolls the lowest Attacks roll in the unit if it is below the average
+// roll, unless opts reroll with a policy of their own. perUnit rolls the
+// Attacks once for the whole unit instead of once per model.
 func CalculateAttackDistribution(
 	attacks DiceRoll,
+	opts DiceOptions,
 	attackerCount int,
 	blast bool,
 	targetCount int,
+	rapidFire int,
+	singleReroll bool,
+	perUnit bool,
 ) map[int]float64 {
 
 	// PER-MODEL distribution.
-	perModelDist := getDiceDistribution(attacks)
+	perModelDist := generateDiceDistribution(attacks, opts)
+
+	// [RAPID FIRE X] adds X to each model's Attacks characteristic.
+	perModelDist = shiftDistribution(perModelDist, rapidFire)
 
 	// Blast is applied per model, before scaling to the whole unit.
 	if blast {
 		perModelDist = applyBlastModifier(perModelDist, targetCount)
 	}
 
+	singleReroll = (singleReroll || opts.Reroll == DiceRerollSingle) &&
+		opts.Reroll != DiceRerollBelowAverage && opts.Reroll != DiceRerollBelow &&
+		attacks.Count > 0 && attacks.Sides > 0
+
+	if perUnit {
+		// Every model makes the attacks of the one roll.
+		if singleReroll {
+			perModelDist = scaleWithSingleReroll(perModelDist, 1)
+		}
+		return multiplyDistribution(perModelDist, attackerCount)
+	}
+
+	if singleReroll {
+		return scaleWithSingleReroll(perModelDist, attackerCount)
+	}
+
 	unitDist := scaleByAttackerCount(perModelDist, attackerCount)
 
 	return unitDist
 }
 
-func applyDamageFloor(value int) int {
-	if value < 1 {
-		return 1
+// scaleWithSingleReroll is scaleByAttackerCount when the lowest model's
+// roll is rerolled if it is below the mean. Rapid Fire and Blast shift
+// every model alike, so comparing the shifted values picks the same roll.
+func scaleWithSingleReroll(perModelDist map[int]float64, count int) map[int]float64 {
+	mean := 0.0
+	for val, p := range perModelDist {
+		mean += float64(val) * p
 	}
-	return value
-}
 
-func getDiceDistribution(attacks DiceRoll) map[int]float64 {
+	// Track the unit total jointly with its lowest model; the lowest of
+	// no models is represented by -1.
+	type unitRoll struct{ total, lowest int }
+	current := map[unitRoll]float64{{total: 0, lowest: -1}: 1.0}
+	for i := 0; i < count; i++ {
+		next := make(map[unitRoll]float64)
+		for roll, pRoll := range current {
+			for perModel, pModel := range perModelDist {
+				lowest := roll.lowest
+				if lowest < 0 || perModel < lowest {
+					lowest = perModel
+				}
+				next[unitRoll{total: roll.total + perModel, lowest: lowest}] += pRoll * pModel
+			}
+		}
+		current = next
+	}
 
-	dist := rollDiceDistribution(attacks.Count, attacks.Sides)
-	// Damage/attacks cannot be modified below 1.
-	finalDist := make(map[int]float64)
-	for val, p := range dist {
-		floored := applyDamageFloor(val + attacks.Modifier)
-		finalDist[floored] += p
+	unitDist := make(map[int]float64)
+	for roll, pRoll := range current {
+		if roll.lowest < 0 || float64(roll.lowest) >= mean {
+			unitDist[roll.total] += pRoll
+			continue
+		}
+		for perModel, pModel := range perModelDist {
+			unitDist[roll.total-roll.lowest+perModel] += pRoll * pModel
+		}
 	}
+	return unitDist
+}
 
-	return finalDist
+func applyDamageFloor(value int) int {
+	if value < 1 {
+		return 1
+	}
+	return value
 }
 
 func rollDiceDistribution(numDice, dieType int) map[int]float64 {
@@ -97,6 +152,27 @@ func applyBlastModifier(
 	return out
 }
 
+// shiftDistribution adds a flat bonus to every outcome of a distribution.
+func shiftDistribution(dist map[int]float64, bonus int) map[int]float64 {
+	if bonus == 0 {
+		return dist
+	}
+	out := make(map[int]float64, len(dist))
+	for val, p := range dist {
+		out[val+bonus] += p
+	}
+	return out
+}
+
+// multiplyDistribution multiplies every outcome of a distribution by factor.
+func multiplyDistribution(dist map[int]float64, factor int) map[int]float64 {
+	out := make(map[int]float64, len(dist))
+	for val, p := range dist {
+		out[val*factor] += p
+	}
+	return out
+}
+
 func scaleByAttackerCount(
 	perModelDist map[int]float64,
 	count int,
@@ -31,9 +31,13 @@ func TestCalculateAttackDistribution(t *testing.T) {
 	tests := []struct {
 		name          string
 		attacks       DiceRoll
+		opts          DiceOptions
 		attackerCount int
 		blast         bool
 		targetCount   int
+		rapidFire     int
+		singleReroll  bool
+		perUnit       bool
 		expectedCheck map[int]float64
 	}{
 		{
@@ -143,12 +147,118 @@ func TestCalculateAttackDistribution(t *testing.T) {
 				1: 1.0,
 			},
 		},
+		{
+			name:          "Rapid Fire 1 on 2 models with 2 attacks",
+			attacks:       DiceRoll{Count: 0, Sides: 0, Modifier: 2},
+			attackerCount: 2,
+			targetCount:   1,
+			rapidFire:     1,
+			expectedCheck: map[int]float64{
+				6: 1.0, // 2 × (2 + 1)
+			},
+		},
+		{
+			name:          "d3 with Rapid Fire 2 and Blast (10 targets -> +2)",
+			attacks:       DiceRoll{Count: 1, Sides: 3, Modifier: 0},
+			attackerCount: 1,
+			blast:         true,
+			targetCount:   10,
+			rapidFire:     2,
+			expectedCheck: map[int]float64{
+				5: 1.0 / 3.0,
+				6: 1.0 / 3.0,
+				7: 1.0 / 3.0,
+			},
+		},
+		{
+			// 1-3 is rerolled: each face keeps 1/6 only if it is 4+, and
+			// gains 1/2 · 1/6 from the reroll.
+			name:          "d6 with a single reroll (1 model)",
+			attacks:       DiceRoll{Count: 1, Sides: 6},
+			attackerCount: 1,
+			singleReroll:  true,
+			expectedCheck: map[int]float64{
+				1: 1.0 / 12.0, 2: 1.0 / 12.0, 3: 1.0 / 12.0,
+				4: 1.0 / 4.0, 5: 1.0 / 4.0, 6: 1.0 / 4.0,
+			},
+		},
+		{
+			// Only the lower die is rerolled, and only a 1 (mean 2):
+			// (1,1) -> 1+d3, (1,x) -> x+d3, otherwise unchanged.
+			name:          "2 × d3 with a single reroll",
+			attacks:       DiceRoll{Count: 1, Sides: 3},
+			attackerCount: 2,
+			singleReroll:  true,
+			expectedCheck: map[int]float64{
+				2: 1.0 / 27.0,
+				3: 1.0/27.0 + 2.0/27.0,
+				4: 1.0/27.0 + 2.0/27.0 + 2.0/27.0 + 1.0/9.0,
+				5: 2.0/27.0 + 2.0/27.0 + 2.0/9.0,
+				6: 2.0/27.0 + 1.0/9.0,
+			},
+		},
+		{
+			name:          "d6 counting results below 3 as 3",
+			attacks:       DiceRoll{Count: 1, Sides: 6},
+			opts:          DiceOptions{Minimum: 3},
+			attackerCount: 1,
+			expectedCheck: map[int]float64{
+				3: 1.0 / 2.0, 4: 1.0 / 6.0, 5: 1.0 / 6.0, 6: 1.0 / 6.0,
+			},
+		},
+		{
+			name:          "d6 rerolling below-average rolls",
+			attacks:       DiceRoll{Count: 1, Sides: 6},
+			opts:          DiceOptions{Reroll: DiceRerollBelowAverage},
+			attackerCount: 1,
+			expectedCheck: map[int]float64{
+				1: 1.0 / 12.0, 2: 1.0 / 12.0, 3: 1.0 / 12.0,
+				4: 1.0 / 4.0, 5: 1.0 / 4.0, 6: 1.0 / 4.0,
+			},
+		},
+		{
+			// The profile's own single reroll behaves like the shared one.
+			name:          "d6 with a single reroll policy (1 model)",
+			attacks:       DiceRoll{Count: 1, Sides: 6},
+			opts:          DiceOptions{Reroll: DiceRerollSingle},
+			attackerCount: 1,
+			expectedCheck: map[int]float64{
+				1: 1.0 / 12.0, 2: 1.0 / 12.0, 3: 1.0 / 12.0,
+				4: 1.0 / 4.0, 5: 1.0 / 4.0, 6: 1.0 / 4.0,
+			},
+		},
+		{
+			// Rapid Fire is added to the one roll before every model uses it.
+			name:          "2 × d3 rolled once for the unit, Rapid Fire 1",
+			attacks:       DiceRoll{Count: 1, Sides: 3},
+			attackerCount: 2,
+			rapidFire:     1,
+			perUnit:       true,
+			expectedCheck: map[int]float64{
+				4: 1.0 / 3.0, 6: 1.0 / 3.0, 8: 1.0 / 3.0,
+			},
+		},
+		{
+			name:          "3 × d6 rolled once for the unit, minimum 3 and a single reroll",
+			attacks:       DiceRoll{Count: 1, Sides: 6},
+			opts:          DiceOptions{Minimum: 3},
+			attackerCount: 3,
+			singleReroll:  true,
+			perUnit:       true,
+			// The mean is 4, so a 3 is rerolled: 3 -> 1/2 · 1/2.
+			expectedCheck: map[int]float64{
+				9:  1.0 / 4.0,
+				12: 1.0/6.0 + 1.0/12.0,
+				15: 1.0/6.0 + 1.0/12.0,
+				18: 1.0/6.0 + 1.0/12.0,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Logic now returns map directly since parsing errors are moved to DTO layer
-			gotDist := CalculateAttackDistribution(tt.attacks, tt.attackerCount, tt.blast, tt.targetCount)
+			gotDist := CalculateAttackDistribution(tt.attacks, tt.opts, tt.attackerCount, tt.blast, tt.targetCount, tt.rapidFire, tt.singleReroll, tt.perUnit)
 
 			for val, expectedProb := range tt.expectedCheck {
 				gotProb, exists := gotDist[val]
@@ -22,6 +22,8 @@ package calculator
 
 import (
 	"fmt"
+	"math"
+	"sort"
 )
 
 // Probability-pruning thresholds. Every recursive/convolution step in this
@@ -54,8 +56,12 @@ const (
 // Validator defines the contract for complexity/safety checks.
 type Validator func(*CombatSimulationRequest) error
 
+// SequenceValidator is the Validator of a SequenceRequest.
+type SequenceValidator func(*SequenceRequest) error
+
 type DamageCalculatorImpl struct {
-	Validator Validator
+	Validator         Validator
+	SequenceValidator SequenceValidator
 }
 
 // CalculateDamageCore is the main entry point for the probability engine.
@@ -63,8 +69,9 @@ type DamageCalculatorImpl struct {
 // calculates the mathematical probability of every possible branch in the
 // attack sequence (Hit -> Wound -> Save -> Damage).
 //
-// Pipeline shape: attackCountDist and hitOutcomeDist are independent, each
-// derived only from req; computeHitBounds runs after both because it
+// Pipeline shape (per weapon profile, see simulateWeaponVolley):
+// attackCountDist and hitOutcomeDist are independent, each derived only
+// from req; computeHitBounds runs after both because it
 // inspects their outcome keys to size every matrix downstream.
 // computeAutoWoundNormalHitDist combines the two into a single hit-count
 // distribution. computeJointWoundDist is where the normal and devastating
@@ -74,74 +81,504 @@ type DamageCalculatorImpl struct {
 // computeDamageAllocation each derive one output distribution
 // independently; computeFinalHitsDist is the exception, derived directly
 // from the hit-count distribution rather than the wound distribution,
-// since hits are counted before any wound roll happens.
+// since hits are counted before any wound roll happens. With several
+// profiles, each one's damage allocation picks up from the target wound
+// states the previous profile left behind.
 func (d *DamageCalculatorImpl) CalculateDamageCore(req CombatSimulationRequest) (SimulationResult, error) {
+	if req.Target.WoundsRemainingDist != nil {
+		return d.calculateFromWoundsDist(req)
+	}
+
 	// Hydrate always runs; Validate uses the default unless overridden.
 	d.Hydrate(&req)
+	if err := d.validate(&req); err != nil {
+		return SimulationResult{}, err
+	}
 
-	validate := d.Validator
-	if validate == nil {
-		validate = DefaultComplexityValidator
+	profiles := req.profiles()
+	layout, err := newTargetLayout(req.Target, usesPrecision(profiles))
+	if err != nil {
+		return SimulationResult{}, err
+	}
+	volleys, settings, err := d.prepareVolleys(req, layout, layout.initialStates())
+	if err != nil {
+		return SimulationResult{}, err
+	}
+
+	hasGroups := len(req.Target.Groups) > 0
+
+	// The profiles fire in order at the same target: each allocation
+	// starts from the wound states the previous one left behind. Hits,
+	// wounds and damage don't depend on that state, so their joint
+	// distributions are plain convolutions. In a unit of mixed models the
+	// damage does depend on which model takes it, so it is tracked jointly
+	// with the wound states instead.
+	states := layout.initialStates()
+	dealt := newDealtStates(states)
+	identity := map[int]float64{0: 1.0}
+	hits, wounds, pens, damage, mortals := identity, identity, identity, identity, identity
+	selfDestroyed := identity
+	var breakdown []SimulationResult
+	for i, v := range volleys {
+		if hasGroups {
+			dealt = v.allocateDealt(dealt)
+		} else {
+			var damageVec []float64
+			states, damageVec = v.allocate(states)
+			damage = pruneDist(convolveDist(damage, vectorToMap(damageVec)))
+		}
+
+		hits = pruneDist(convolveDist(hits, v.hits))
+		wounds = pruneDist(convolveDist(wounds, v.wounds))
+		pens = pruneDist(convolveDist(pens, v.pens))
+		mortals = pruneDist(convolveDist(mortals, v.mortals))
+		selfDestroyed = pruneDist(convolveDist(selfDestroyed, v.selfDestroyed))
+
+		if len(volleys) > 1 {
+			var alone, aloneDamage []float64
+			if hasGroups {
+				aloneDealt := v.allocateDealt(newDealtStates(layout.initialStates()))
+				alone, aloneDamage = aloneDealt.states(), aloneDealt.damage()
+			} else {
+				alone, aloneDamage = v.allocate(layout.initialStates())
+			}
+			weaponResult := formatResponse(
+				v.hits, v.wounds, v.pens, vectorToMap(aloneDamage),
+				vectorToMap(layout.killed(alone)),
+				v.mortals, req.Points,
+			)
+			weaponResult.SelfDestroyedDist = v.selfDestroyed
+			setThresholdResults(&weaponResult, req.Thresholds, layout.aliveAtStart())
+			setRemainingWounds(&weaponResult, layout, alone)
+			setGroupResults(&weaponResult, layout, alone, hasGroups)
+			setEffectiveModifiers(&weaponResult, settings[i])
+			breakdown = append(breakdown, weaponResult)
+		}
+	}
+	if hasGroups {
+		states, damage = dealt.states(), vectorToMap(dealt.damage())
 	}
 
-	if err := validate(&req); err != nil {
+	result := formatResponse(
+		hits, wounds, pens, damage,
+		vectorToMap(layout.killed(states)),
+		mortals, req.Points,
+	)
+	result.SelfDestroyedDist = selfDestroyed
+	setThresholdResults(&result, req.Thresholds, layout.aliveAtStart())
+	setRemainingWounds(&result, layout, states)
+	setGroupResults(&result, layout, states, hasGroups)
+	setEffectiveModifiers(&result, settings[0])
+	result.Weapons = breakdown
+	return result, nil
+}
+
+// calculateFromWoundsDist resolves a request whose target starts from a
+// PMF of the wounds it has left: once from each of them, the results
+// mixed by their probabilities.
+func (d *DamageCalculatorImpl) calculateFromWoundsDist(req CombatSimulationRequest) (SimulationResult, error) {
+	if req.Target.WoundsRemaining != nil {
+		return SimulationResult{}, fmt.Errorf("wounds remaining and its distribution cannot both be set")
+	}
+	whole := req
+	d.Hydrate(&whole)
+	if err := d.validate(&whole); err != nil {
 		return SimulationResult{}, err
 	}
 
-	targetCount := *req.Target.Count
+	lefts := make([]int, 0, len(req.Target.WoundsRemainingDist))
+	for left := range req.Target.WoundsRemainingDist {
+		lefts = append(lefts, left)
+	}
+	sort.Ints(lefts)
+
+	results := make([]SimulationResult, len(lefts))
+	weights := make([]float64, len(lefts))
+	for i, left := range lefts {
+		start := req
+		start.Target.WoundsRemaining = &left
+		start.Target.WoundsRemainingDist = nil
+		res, err := d.CalculateDamageCore(start)
+		if err != nil {
+			return SimulationResult{}, err
+		}
+		results[i], weights[i] = res, req.Target.WoundsRemainingDist[left]
+	}
+	return mixResults(results, weights, req.Points), nil
+}
+
+// mixResults returns the weighted mixture of the results of one request
+// resolved from different start states. Every distribution and
+// probability is the weighted sum of the results'; the averages and
+// statistics are derived again from the mixed distributions.
+func mixResults(results []SimulationResult, weights []float64, points UnitPoints) SimulationResult {
+	mix := func(dist func(SimulationResult) map[int]float64) map[int]float64 {
+		var res map[int]float64
+		for i, r := range results {
+			if dist(r) == nil {
+				continue
+			}
+			if res == nil {
+				res = make(map[int]float64)
+			}
+			for k, p := range dist(r) {
+				res[k] += weights[i] * p
+			}
+		}
+		return res
+	}
+	mixValue := func(value func(SimulationResult) float64) float64 {
+		res := 0.0
+		for i, r := range results {
+			res += weights[i] * value(r)
+		}
+		return res
+	}
+
+	result := formatResponse(
+		mix(func(r SimulationResult) map[int]float64 { return r.HitDist }),
+		mix(func(r SimulationResult) map[int]float64 { return r.WoundDist }),
+		mix(func(r SimulationResult) map[int]float64 { return r.PenDist }),
+		mix(func(r SimulationResult) map[int]float64 { return r.DamageDist }),
+		mix(func(r SimulationResult) map[int]float64 { return r.DestroyedDist }),
+		mix(func(r SimulationResult) map[int]float64 { return r.MortalWoundDist }),
+		points,
+	)
+	result.WoundsLostDist = mix(func(r SimulationResult) map[int]float64 { return r.WoundsLostDist })
+	result.DestroyedAtLeast = mix(func(r SimulationResult) map[int]float64 { return r.DestroyedAtLeast })
+	result.DamageAtLeast = mix(func(r SimulationResult) map[int]float64 { return r.DamageAtLeast })
+	result.WipeProb = mixValue(func(r SimulationResult) float64 { return r.WipeProb })
+	result.DestroyedTailMass = tailMass(result.DestroyedDist)
+	result.DamageTailMass = tailMass(result.DamageDist)
+	result.RemainingWoundsDist = mix(func(r SimulationResult) map[int]float64 { return r.RemainingWoundsDist })
+	result.WoundedModelDist = mix(func(r SimulationResult) map[int]float64 { return r.WoundedModelDist })
+	result.CharacterDestroyedProb = mixValue(func(r SimulationResult) float64 { return r.CharacterDestroyedProb })
+	result.SelfDestroyedDist = mix(func(r SimulationResult) map[int]float64 { return r.SelfDestroyedDist })
+	result.EffectiveHitModifier = results[0].EffectiveHitModifier
+	result.EffectiveWoundModifier = results[0].EffectiveWoundModifier
+
+	for gi, g := range results[0].Groups {
+		result.Groups = append(result.Groups, GroupResult{
+			Name:          g.Name,
+			DestroyedDist: mix(func(r SimulationResult) map[int]float64 { return r.Groups[gi].DestroyedDist }),
+		})
+	}
+	for wi := range results[0].Weapons {
+		weapon := make([]SimulationResult, len(results))
+		for i, r := range results {
+			weapon[i] = r.Weapons[wi]
+		}
+		result.Weapons = append(result.Weapons, mixResults(weapon, weights, points))
+	}
+	return result
+}
+
+// validate runs the calculator's Validator, or DefaultComplexityValidator
+// if it has none, over a hydrated request.
+func (d *DamageCalculatorImpl) validate(req *CombatSimulationRequest) error {
+	if d.Validator == nil {
+		return DefaultComplexityValidator(req)
+	}
+	return d.Validator(req)
+}
+
+// profiles lists the attacker's profile followed by its weapons, in the
+// order they fire.
+func (r CombatSimulationRequest) profiles() []AttackerProfile {
+	return append([]AttackerProfile{r.Attacker}, r.Weapons...)
+}
+
+// usesPrecision reports whether any of the profiles has Precision, which
+// lets its damage reach an attached Character first.
+func usesPrecision(profiles []AttackerProfile) bool {
+	for _, profile := range profiles {
+		if profile.Precision {
+			return true
+		}
+	}
+	return false
+}
+
+// usesBlast reports whether any of the profiles has Blast.
+func usesBlast(profiles []AttackerProfile) bool {
+	for _, profile := range profiles {
+		if profile.Blast {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareVolleys resolves every profile of a hydrated, validated request
+// up to the point where its damage meets the target's wound states,
+// returning one volley per profile with the settings it ended up rolling
+// with. The profiles fire in order at a target in the given states, which
+// RerollOptimal is chosen against.
+func (d *DamageCalculatorImpl) prepareVolleys(req CombatSimulationRequest, layout *targetLayout, states []float64) ([]weaponVolley, []SimulationSettings, error) {
+	profiles := req.profiles()
+	if len(profiles) > 1 && req.Settings.SingleReroll != (SingleRerolls{}) {
+		return nil, nil, fmt.Errorf("single rerolls are not supported with several weapon profiles")
+	}
+
+	// Every profile gets its own copy of the request: weapon abilities
+	// change the settings and target of the profile that has them only.
+	optimal := req.Settings.HitReroll == RerollOptimal || req.Settings.WoundReroll == RerollOptimal
+	volleys := make([]weaponVolley, len(profiles))
+	settings := make([]SimulationSettings, len(profiles))
+	for i, profile := range profiles {
+		weaponReq := req
+		weaponReq.Attacker = profile
+		weaponReq.Weapons = nil
+		if len(req.Target.Groups) > 0 && req.Settings.SingleReroll.Save &&
+			(req.Settings.SingleReroll.Damage || profile.DamageOptions.Reroll == DiceRerollSingle) {
+			return nil, nil, fmt.Errorf("a single save reroll cannot be combined with a single damage reroll against model groups")
+		}
+		applyTargetAbilities(&weaponReq)
+		applyWeaponAbilities(&weaponReq)
+		weaponReq = chooseOptimalRerolls(weaponReq, layout, states)
+		volleys[i] = simulateWeaponVolley(weaponReq, layout)
+		settings[i] = weaponReq.Settings
+		if optimal && i < len(profiles)-1 {
+			// The next profile chooses its rerolls against the target
+			// this one leaves behind.
+			states, _ = volleys[i].allocate(states)
+		}
+	}
+	return volleys, settings, nil
+}
+
+// setEffectiveModifiers reports the net hit and wound modifiers a profile
+// rolled with, once applyWeaponAbilities has resolved them.
+func setEffectiveModifiers(result *SimulationResult, settings SimulationSettings) {
+	result.EffectiveHitModifier = settings.HitModifier
+	result.EffectiveWoundModifier = settings.WoundModifier
+}
+
+// setThresholdResults fills in the odds of reaching the requested
+// thresholds and of destroying all modelCount models of the target.
+func setThresholdResults(result *SimulationResult, thresholds ResultThresholds, modelCount int) {
+	result.DestroyedAtLeast = make(map[int]float64, len(thresholds.Destroyed))
+	for _, k := range thresholds.Destroyed {
+		result.DestroyedAtLeast[k] = probAtLeast(result.DestroyedDist, k)
+	}
+	result.DamageAtLeast = make(map[int]float64, len(thresholds.Damage))
+	for _, d := range thresholds.Damage {
+		result.DamageAtLeast[d] = probAtLeast(result.DamageDist, d)
+	}
+	result.WipeProb = probAtLeast(result.DestroyedDist, modelCount)
+	result.DestroyedTailMass = tailMass(result.DestroyedDist)
+	result.DamageTailMass = tailMass(result.DamageDist)
+}
+
+// setRemainingWounds fills in the wound state the target is left in.
+func setRemainingWounds(result *SimulationResult, layout *targetLayout, states []float64) {
+	result.WoundsLostDist = vectorToMap(layout.woundsLost(states))
+	result.RemainingWoundsDist = vectorToMap(layout.remainingWounds(states))
+	result.WoundedModelDist = vectorToMap(layout.nextModelWounds(states))
+}
+
+// setGroupResults fills in the per-group results of a target built from
+// Groups: the destroyed-model PMFs and the odds of losing the designated
+// Character.
+func setGroupResults(result *SimulationResult, layout *targetLayout, states []float64, hasGroups bool) {
+	if !hasGroups {
+		return
+	}
+	for gi, killed := range layout.groupKilled(states) {
+		result.Groups = append(result.Groups, GroupResult{
+			Name:          layout.groups[gi].Name,
+			DestroyedDist: vectorToMap(killed),
+		})
+		if gi == layout.character {
+			result.CharacterDestroyedProb = killed[len(killed)-1]
+		}
+	}
+}
+
+// optimalRerollCandidates are the policies RerollOptimal chooses between.
+// Rerolling ones is never better than rerolling every failure.
+var optimalRerollCandidates = []RerollType{RerollFail, RerollNonCritical}
+
+// chooseOptimalRerolls replaces RerollOptimal on the hit and wound rolls of
+// a single profile with the candidate policies that destroy the most
+// models on average, firing at a target in the given wound states. Ties
+// keep the earlier candidate, so failures are rerolled unless fishing is
+// better.
+func chooseOptimalRerolls(req CombatSimulationRequest, layout *targetLayout, states []float64) CombatSimulationRequest {
+	hitOptions := []RerollType{req.Settings.HitReroll}
+	if req.Settings.HitReroll == RerollOptimal {
+		hitOptions = optimalRerollCandidates
+	}
+	woundOptions := []RerollType{req.Settings.WoundReroll}
+	if req.Settings.WoundReroll == RerollOptimal {
+		woundOptions = optimalRerollCandidates
+	}
+	if len(hitOptions) == 1 && len(woundOptions) == 1 {
+		return req
+	}
+
+	best, bestDestroyed := req, -1.0
+	for _, hitReroll := range hitOptions {
+		for _, woundReroll := range woundOptions {
+			candidate := req
+			candidate.Settings.HitReroll = hitReroll
+			candidate.Settings.WoundReroll = woundReroll
+
+			after, _ := simulateWeaponVolley(candidate, layout).allocate(states)
+			destroyed := 0.0
+			for k, p := range layout.killed(after) {
+				destroyed += float64(k) * p
+			}
+			if destroyed > bestDestroyed+negligibleProbability {
+				best, bestDestroyed = candidate, destroyed
+			}
+		}
+	}
+	return best
+}
+
+// weaponVolley is one weapon profile's volley, resolved up to the point
+// where it meets the target's wound states.
+type weaponVolley struct {
+	hits, wounds, pens, mortals map[int]float64
+	// selfDestroyed is the PMF of the attacking models the volley's
+	// Hazardous tests destroy.
+	selfDestroyed map[int]float64
+
+	// streamDist is the wounds that reach allocation: against Groups
+	// before their saving throws, which the per-group damage PMFs fold
+	// in, and otherwise after them.
+	streamDist map[woundStreams]float64
+	damage     []groupDamage
+	layout     *targetLayout
+}
+
+// allocate resolves the volley against the given target wound states,
+// returning the resulting states and the PMF of the damage it dealt.
+func (v weaponVolley) allocate(states []float64) (finalStates, damageVec []float64) {
+	return computeDamageAllocation(states, v.streamDist, failedSaves(), v.damage, v.layout)
+}
+
+// simulateWeaponVolley runs the pipeline for a single weapon profile, whose
+// abilities have already been applied to req.
+func simulateWeaponVolley(req CombatSimulationRequest, layout *targetLayout) weaponVolley {
+	// Blast counts the models left in the target unit, not its full size.
+	targetCount := layout.aliveAtStart()
+	if req.Attacker.Precision {
+		layout = layout.withPrecision()
+	}
+
+	// In a unit of mixed models the save depends on the model allocated
+	// to; the failed-save distribution reports the saves of the models
+	// that are allocated attacks first.
+	save, invulnerable := req.Target.Save, req.Target.Invulnerable
+	if len(req.Target.Groups) > 0 {
+		first := layout.firstGroup()
+		save, invulnerable = first.Save, first.Invulnerable
+	}
 
 	attackCountDist := CalculateAttackDistribution(
 		req.Attacker.Attacks,
+		req.Attacker.AttacksOptions,
 		req.Attacker.Count,
 		req.Attacker.Blast,
 		targetCount,
+		halfRangeBonus(req.Attacker.RapidFireX, req.Settings.HalfRange),
+		req.Settings.SingleReroll.Attacks,
+		req.Attacker.AttacksRolledPerUnit,
 	)
 
 	hitOutcomeDist := computeHitOutcomeDist(req)
 
-	probNormalWound, probDevWound := CalculateWoundProbability(
-		req.Attacker.Strength,
-		req.Target.Toughness,
-		req.Settings.WoundReroll,
-		req.Settings.WoundModifier,
-		req.Attacker.DevastatingWounds,
-		req.Settings.CriticalWoundThreshold,
-	)
+	woundOutcomeDist := computeWoundOutcomeDist(req)
+
+	// The old [DEVASTATING WOUNDS] wording also takes its Critical Wounds
+	// past the saving throw, so the hit/wound/pen distributions treat them
+	// like devastating wounds.
+	devastating := req.Attacker.DevastatingWounds || req.Attacker.LegacyDevastatingWounds
+	probNormalWound, probDevWound := splitWoundOutcomes(woundOutcomeDist, devastating)
+
+	saves := saveRolls{
+		probFailed: CalculateFailedSaveProbability(
+			req.Attacker.AP,
+			save,
+			invulnerable,
+			req.Settings.SaveModifier,
+			req.Target.HasCover,
+			req.Settings.SaveReroll,
+		),
+		singleReroll: req.Settings.SingleReroll.Save,
+	}
+	if saves.singleReroll {
+		saves.eligible, saves.freshFailed = singleSaveReroll(
+			req.Attacker.AP,
+			save,
+			invulnerable,
+			req.Settings.SaveModifier,
+			req.Target.HasCover,
+			req.Settings.SaveReroll,
+		)
+	}
 
-	probSaveFailed := CalculateFailedSaveProbability(
-		req.Attacker.AP,
-		req.Target.Save,
-		req.Target.Invulnerable,
-		req.Settings.SaveModifier,
-		req.Target.HasCover,
-		req.Settings.SaveReroll,
-	)
+	// The wounds of the critical classes are saved at their own AP. A
+	// single save reroll is not spent on them.
+	classes := classSaves{saves}
+	for class := 1; class < numWoundClasses; class++ {
+		classes[class] = saveRolls{probFailed: CalculateFailedSaveProbability(
+			classAP(req.Attacker, woundClass(class)),
+			save,
+			invulnerable,
+			req.Settings.SaveModifier,
+			req.Target.HasCover,
+			req.Settings.SaveReroll,
+		)}
+	}
 
 	bounds := computeHitBounds(attackCountDist, hitOutcomeDist)
 
-	finalAutoWoundNormalHitDist := computeAutoWoundNormalHitDist(hitOutcomeDist, attackCountDist, bounds)
+	finalAutoWoundNormalHitDist := computeAutoWoundNormalHitDist(hitOutcomeDist, attackCountDist, bounds, computeSingleHitReroll(req))
 
-	jointWoundDist := computeJointWoundDist(finalAutoWoundNormalHitDist, bounds, probNormalWound, probDevWound)
+	jointWoundDist := computeJointWoundDist(finalAutoWoundNormalHitDist, bounds, probNormalWound, probDevWound,
+		computeSingleWoundReroll(req, devastating))
 
 	finalHitsDist := computeFinalHitsDist(finalAutoWoundNormalHitDist, bounds)
 
 	totalWoundsDist := computeTotalWoundsDist(jointWoundDist, bounds.maxHits)
 
-	finalUnsavedDist := computeFinalUnsavedDist(jointWoundDist, bounds.maxHits, probSaveFailed)
+	finalUnsavedDist := computeFinalUnsavedDist(jointWoundDist, bounds.maxHits, saves)
 
-	finalKilledSlice, totalDamageVec := computeDamageAllocation(
-		jointWoundDist, bounds.maxHits, probSaveFailed,
-		req.Attacker.Damage, req.Target.FeelNoPain,
-		req.Target.WoundsPerModel, targetCount,
-	)
+	streamDist := jointWoundStreams(jointWoundDist, bounds.maxHits)
+	wounds, pens := vectorToMap(totalWoundsDist), vectorToMap(finalUnsavedDist)
+	mortalWoundDist := map[int]float64{0: 1.0}
+	tracksCritical := criticalHitEffects(req.Attacker).tracksCriticalHits() || tracksCriticalWounds(req.Attacker)
+	if hasMortalWoundSource(req.Attacker) || tracksCritical {
+		streamDist = volleyWoundStreamsWithRerolls(req, woundOutcomeDist, attackCountDist)
+		mortalWoundDist = mortalWoundMarginal(streamDist)
+	}
+	if tracksCritical {
+		// The dense matrices wound and save every hit alike.
+		wounds, pens = streamWoundDists(streamDist, classes)
+	}
+
+	// Against Groups the saving throws are folded into the per-group
+	// damage PMFs. Otherwise they are rolled here, once, and the wounds
+	// that get through are only told apart by the damage they deal.
+	if len(req.Target.Groups) == 0 {
+		streamDist = unsavedStreams(streamDist, classes.unsavedByDamage)
+	}
+
+	return weaponVolley{
+		hits:    vectorToMap(finalHitsDist),
+		wounds:  wounds,
+		pens:    pens,
+		mortals: mortalWoundDist,
 
-	return formatResponse(
-		vectorToMap(finalHitsDist),
-		vectorToMap(totalWoundsDist),
-		vectorToMap(finalUnsavedDist),
-		vectorToMap(totalDamageVec),
-		vectorToMap(finalKilledSlice),
-	), nil
+		selfDestroyed: hazardousDestroyedDist(req.Attacker),
+
+		streamDist: streamDist,
+		damage:     groupDamageDists(req, layout),
+		layout:     layout,
+	}
 }
 
 // hitBounds carries the truncation bounds used to size every dense
@@ -171,14 +608,17 @@ func computeHitBounds(attackCountDist map[int]float64, hitOutcomeDist map[HitOut
 
 	maxNper, maxLper, maxPerTotal := 0, 0, 0
 	for o := range hitOutcomeDist {
-		if o.NormalHits > maxNper {
-			maxNper = o.NormalHits
+		// Critical hits roll to wound, so the matrices count them as
+		// normal hits.
+		normal := o.NormalHits + o.CriticalHits
+		if normal > maxNper {
+			maxNper = normal
 		}
 		if o.LethalHits > maxLper {
 			maxLper = o.LethalHits
 		}
-		if o.NormalHits+o.LethalHits > maxPerTotal {
-			maxPerTotal = o.NormalHits + o.LethalHits
+		if normal+o.LethalHits > maxPerTotal {
+			maxPerTotal = normal + o.LethalHits
 		}
 	}
 
@@ -194,13 +634,29 @@ func computeHitBounds(attackCountDist map[int]float64, hitOutcomeDist map[HitOut
 
 // computeAutoWoundNormalHitDist returns the final collapsed hit distribution
 // (auto wounds × normal hits), summed across every possible attack count.
-func computeAutoWoundNormalHitDist(hitOutcomeDist map[HitOutcome]float64, attackCountDist map[int]float64, bounds hitBounds) AutoWoundNormalHitMatrix {
+// A non-nil single applies a single hit reroll to the whole pool.
+func computeAutoWoundNormalHitDist(hitOutcomeDist map[HitOutcome]float64, attackCountDist map[int]float64, bounds hitBounds, single *singleHitReroll) AutoWoundNormalHitMatrix {
 	singleAttackHitMatrix := BuildSingleAttackHitMatrix(
 		hitOutcomeDist,
 		bounds.maxNormalPerAttack,
 		bounds.maxLethalPerAttack,
 	)
 
+	var keptAttackHitMatrix, freshAttackHitMatrix JointHitProbabilityMatrix
+	if single != nil {
+		keptAttackHitMatrix = BuildSingleAttackHitMatrix(
+			hitOutcomeDist,
+			bounds.maxNormalPerAttack,
+			bounds.maxLethalPerAttack,
+		)
+		keptAttackHitMatrix[0][0] -= single.eligible
+		freshAttackHitMatrix = BuildSingleAttackHitMatrix(
+			single.fresh,
+			bounds.maxNormalPerAttack,
+			bounds.maxLethalPerAttack,
+		)
+	}
+
 	finalAutoWoundNormalHitDist := make(AutoWoundNormalHitMatrix, bounds.maxL+1)
 	for i := range finalAutoWoundNormalHitDist {
 		finalAutoWoundNormalHitDist[i] = make([]float64, bounds.maxN+1)
@@ -211,14 +667,28 @@ func computeAutoWoundNormalHitDist(hitOutcomeDist map[HitOutcome]float64, attack
 			continue
 		}
 
-		jointHitMatrix := ComputeMultiAttackHitDistribution(
-			singleAttackHitMatrix,
-			attackCount,
-			bounds.maxNormalPerAttack,
-			bounds.maxLethalPerAttack,
-			bounds.maxN,
-			bounds.maxL,
-		)
+		var jointHitMatrix JointHitProbabilityMatrix
+		if single != nil {
+			jointHitMatrix = ComputeMultiAttackHitDistributionWithSingleReroll(
+				singleAttackHitMatrix,
+				keptAttackHitMatrix,
+				freshAttackHitMatrix,
+				attackCount,
+				bounds.maxNormalPerAttack,
+				bounds.maxLethalPerAttack,
+				bounds.maxN,
+				bounds.maxL,
+			)
+		} else {
+			jointHitMatrix = ComputeMultiAttackHitDistribution(
+				singleAttackHitMatrix,
+				attackCount,
+				bounds.maxNormalPerAttack,
+				bounds.maxLethalPerAttack,
+				bounds.maxN,
+				bounds.maxL,
+			)
+		}
 
 		autoWoundNormalHitMatrix :=
 			CollapseLethalHitsIntoAutoWounds(jointHitMatrix, bounds.maxN, bounds.maxL)
@@ -246,8 +716,9 @@ type NormalDevastatingWoundMatrix [][]float64
 // state into wounds: normal hits roll to wound independently (binomAny),
 // and each of those wounds is further split into normal vs. devastating
 // (binomDev, conditioned on having already wounded). Auto-wounds (from
-// Lethal Hits) always wound, so they pass straight through.
-func computeJointWoundDist(autoWoundNormalHitDist AutoWoundNormalHitMatrix, bounds hitBounds, probNormalWound, probDevWound float64) NormalDevastatingWoundMatrix {
+// Lethal Hits) always wound, so they pass straight through. A non-nil
+// single applies a single wound reroll to the wound rolls of each state.
+func computeJointWoundDist(autoWoundNormalHitDist AutoWoundNormalHitMatrix, bounds hitBounds, probNormalWound, probDevWound float64, single *singleWoundReroll) NormalDevastatingWoundMatrix {
 	jointWoundDist := make(NormalDevastatingWoundMatrix, bounds.maxHits+1)
 	for i := range jointWoundDist {
 		jointWoundDist[i] = make([]float64, bounds.maxHits+1)
@@ -267,8 +738,18 @@ func computeJointWoundDist(autoWoundNormalHitDist AutoWoundNormalHitMatrix, boun
 	binomAny := precomputeBinomials(bounds.maxN, pAnyWound)
 	binomDev := precomputeBinomials(bounds.maxN, pDevCond) // max wounds <= maxN
 
-	for autoWounds := 0; autoWounds <= bounds.maxL; autoWounds++ {
-		for normalHits := 0; normalHits <= bounds.maxN; normalHits++ {
+	// Without the failures that may use a single reroll, a wound roll
+	// wounds with probability pAnyWound/keptMass; the split is unchanged.
+	keptMass := 1.0
+	var binomKept [][]float64
+	if single != nil {
+		keptMass = 1.0 - single.eligible
+		binomKept = precomputeBinomials(bounds.maxN, pAnyWound/keptMass)
+	}
+
+	for normalHits := 0; normalHits <= bounds.maxN; normalHits++ {
+		var woundDist [][]float64
+		for autoWounds := 0; autoWounds <= bounds.maxL; autoWounds++ {
 			pState := autoWoundNormalHitDist[autoWounds][normalHits]
 			if pState < negligibleProbability {
 				continue
@@ -281,20 +762,16 @@ func computeJointWoundDist(autoWoundNormalHitDist AutoWoundNormalHitMatrix, boun
 			}
 
 			// Roll to wound for normal hits only.
-			for totalWounds, pWound := range binomAny[normalHits] {
-				if pWound < negligibleProbability {
-					continue
-				}
-
-				// Split wounds into normal vs devastating.
-				for devWounds, pDev := range binomDev[totalWounds] {
-					pFinal := pState * pWound * pDev
+			if woundDist == nil {
+				woundDist = normalHitWoundDist(normalHits, binomAny, binomDev, keptMass, binomKept, single)
+			}
+			for nw, row := range woundDist {
+				for devWounds, pWound := range row {
+					pFinal := pState * pWound
 					if pFinal < negligibleProbability {
 						continue
 					}
-
-					normWounds := autoWounds + (totalWounds - devWounds)
-					jointWoundDist[normWounds][devWounds] += pFinal
+					jointWoundDist[autoWounds+nw][devWounds] += pFinal
 				}
 			}
 		}
@@ -303,6 +780,96 @@ func computeJointWoundDist(autoWoundNormalHitDist AutoWoundNormalHitMatrix, boun
 	return jointWoundDist
 }
 
+// singleWoundReroll is the effect of a single wound reroll on the pool of
+// wound rolls: eligible is the chance that a wound roll fails with no
+// other reroll to use, and probNormal and probDev split the rerolled die.
+type singleWoundReroll struct {
+	eligible, probNormal, probDev float64
+}
+
+// computeSingleWoundReroll returns the single wound reroll of req, or nil
+// if it has none. probNormal and probDev split the rerolled die the way
+// splitWoundOutcomes splits woundOutcomeDist for devastating.
+func computeSingleWoundReroll(req CombatSimulationRequest, devastating bool) *singleWoundReroll {
+	if !req.Settings.SingleReroll.Wound {
+		return nil
+	}
+	eligible := singleWoundRerollEligible(
+		req.Attacker.Strength,
+		req.Target.Toughness,
+		req.Settings.WoundReroll,
+		req.Settings.WoundModifier,
+		req.Settings.CriticalWoundThreshold,
+		req.Attacker.Anti,
+		req.Target.Keywords,
+		req.Target.WoundRollsFailUpTo,
+	)
+	if eligible <= 0 || eligible >= 1 {
+		// Either no roll can use it, or no roll can ever wound.
+		return nil
+	}
+
+	// The rerolled die cannot be rerolled again.
+	fresh := req
+	fresh.Settings.WoundReroll = RerollNone
+	probNormal, probDev := splitWoundOutcomes(computeWoundOutcomeDist(fresh), devastating)
+	return &singleWoundReroll{eligible: eligible, probNormal: probNormal, probDev: probDev}
+}
+
+// normalHitWoundDist returns the joint PMF of (normal, devastating) wounds
+// from n normal hits. With a single reroll, a failed roll adds nothing, so
+// the pool is exactly kept^n + fresh ⊛ (all^n − kept^n), where kept^n
+// (keptMass^n times binomKept) is the mass in which no roll can use it.
+func normalHitWoundDist(n int, binomAny, binomDev [][]float64, keptMass float64, binomKept [][]float64, single *singleWoundReroll) [][]float64 {
+	dist := make([][]float64, n+1)
+	for nw := range dist {
+		dist[nw] = make([]float64, n+1)
+	}
+	for totalWounds, pWound := range binomAny[n] {
+		if pWound < negligibleProbability {
+			continue
+		}
+		// Split wounds into normal vs devastating.
+		for devWounds, pDev := range binomDev[totalWounds] {
+			dist[totalWounds-devWounds][devWounds] += pWound * pDev
+		}
+	}
+	if single == nil {
+		return dist
+	}
+
+	kept := make([][]float64, n+1)
+	for nw := range kept {
+		kept[nw] = make([]float64, n+1)
+	}
+	scale := powFloat(keptMass, n)
+	for totalWounds, pWound := range binomKept[n] {
+		for devWounds, pDev := range binomDev[totalWounds] {
+			kept[totalWounds-devWounds][devWounds] += scale * pWound * pDev
+		}
+	}
+
+	probMiss := 1.0 - single.probNormal - single.probDev
+	result := make([][]float64, n+1)
+	for nw := range result {
+		result[nw] = append([]float64(nil), kept[nw]...)
+	}
+	for nw := range dist {
+		for dw := range dist[nw] {
+			rerolled := dist[nw][dw] - kept[nw][dw]
+			if nw+dw >= n {
+				// Every roll wounded, so none was rerolled; any mass
+				// left here is rounding error.
+				continue
+			}
+			result[nw][dw] += rerolled * probMiss
+			result[nw+1][dw] += rerolled * single.probNormal
+			result[nw][dw+1] += rerolled * single.probDev
+		}
+	}
+	return result
+}
+
 // computeFinalHitsDist returns the total hit distribution (Normal + Auto),
 // summing autoWounds (from Lethal Hits) and normalHits per state.
 func computeFinalHitsDist(autoWoundNormalHitDist AutoWoundNormalHitMatrix, bounds hitBounds) []float64 {
@@ -349,7 +916,7 @@ func computeTotalWoundsDist(jointWoundDist NormalDevastatingWoundMatrix, maxHits
 // computeFinalUnsavedDist returns the distribution of unsaved wounds
 // (unsaved normal wounds + devastating wounds, which bypass the save roll
 // entirely).
-func computeFinalUnsavedDist(jointWoundDist NormalDevastatingWoundMatrix, maxHits int, probSaveFailed float64) []float64 {
+func computeFinalUnsavedDist(jointWoundDist NormalDevastatingWoundMatrix, maxHits int, saves saveRolls) []float64 {
 	finalUnsavedDist := make([]float64, maxHits+1)
 	for nw := 0; nw <= maxHits; nw++ {
 		for dw := 0; dw <= maxHits; dw++ {
@@ -357,7 +924,7 @@ func computeFinalUnsavedDist(jointWoundDist NormalDevastatingWoundMatrix, maxHit
 			if pJoint < negligibleProbability {
 				continue
 			}
-			unsavedNormal := getBinomialVector(nw, probSaveFailed)
+			unsavedNormal := saves.unsavedDist(nw)
 			for u, pU := range unsavedNormal {
 				if pU < negligibleProbability {
 					continue
@@ -370,73 +937,389 @@ func computeFinalUnsavedDist(jointWoundDist NormalDevastatingWoundMatrix, maxHit
 	return finalUnsavedDist
 }
 
-// computeDamageAllocation resolves each (unsavedNormal, devastating) wound
-// state into destroyed models and total damage dealt.
-//
-// TODO: Some units grant Feel No Pain that explicitly excludes devastating
-// wound damage. Not modeled — FNP is currently applied uniformly to both
-// normal and devastating damage via dmgDist.
-func computeDamageAllocation(
-	jointWoundDist NormalDevastatingWoundMatrix,
-	maxHits int,
-	probSaveFailed float64,
-	damage DiceRoll,
-	feelNoPain *int,
-	woundsPerModel, targetCount int,
-) (killed, damageVec []float64) {
-	dmgDist := _calculateDamageDistribution(damage, feelNoPain)
-	finalKilledSlice := make([]float64, targetCount+1)
-
-	maxD := GetMaxFromDice(damage)
-	totalDamageVec := make([]float64, maxHits*maxD+1)
-	// Pre-calculate convolutions for each possible total hit count [0...maxHits]
-	// damageConvs[hits][damage]
-	damageConvs := make([][]float64, maxHits+1)
-	damageConvs[0] = []float64{1.0}
+// saveRolls describes the saving throws made against normal wounds.
+type saveRolls struct {
+	probFailed float64
+	// With a single save reroll, eligible is the chance that a save fails
+	// with no other reroll to use, and freshFailed the chance that the
+	// rerolled save fails again.
+	singleReroll          bool
+	eligible, freshFailed float64
+}
 
-	for nw := 0; nw <= maxHits; nw++ {
-		row := jointWoundDist[nw]
-		for dw := 0; dw <= maxHits; dw++ {
-			pJoint := row[dw]
-			if pJoint < coarseNegligibleProbability {
-				continue
-			}
+// classSaves are the saving throws made against the wounds of each
+// woundClass.
+type classSaves [numWoundClasses]saveRolls
 
-			unsavedNormalDist := getBinomialVector(nw, probSaveFailed)
-			for u, pU := range unsavedNormalDist {
-				weight := pJoint * pU
-				if weight < negligibleProbability {
+// failedSaves returns saving throws that always fail.
+func failedSaves() classSaves {
+	var saves classSaves
+	for class := range saves {
+		saves[class] = saveRolls{probFailed: 1.0}
+	}
+	return saves
+}
+
+// unsavedCounts returns the joint PMF of the unsaved wounds of each class,
+// among the given saveable wounds.
+func (c classSaves) unsavedCounts(saveable [numWoundClasses]int) map[[numWoundClasses]int]float64 {
+	return c.unsavedJoint(saveable, func(class woundClass) woundClass { return class })
+}
+
+// unsavedByDamage is unsavedCounts with the unsaved wounds of the classes
+// that deal the same damage counted together, under the class of their
+// damage (see woundClass.damageClass). It is only valid for wounds whose
+// damage does not depend on their saving throw.
+func (c classSaves) unsavedByDamage(saveable [numWoundClasses]int) map[[numWoundClasses]int]float64 {
+	return c.unsavedJoint(saveable, woundClass.damageClass)
+}
+
+// unsavedTotal returns the PMF of the unsaved wounds among the given
+// saveable wounds.
+func (c classSaves) unsavedTotal(saveable [numWoundClasses]int) []float64 {
+	var total []float64
+	for counts, p := range c.unsavedJoint(saveable, func(woundClass) woundClass { return 0 }) {
+		for len(total) <= counts[0] {
+			total = append(total, 0)
+		}
+		total[counts[0]] += p
+	}
+	return total
+}
+
+// unsavedJoint returns the joint PMF of the unsaved wounds counted under
+// slot(class) for each class. The classes sharing a slot are convolved
+// first, so the joint PMF only spans the slots.
+func (c classSaves) unsavedJoint(saveable [numWoundClasses]int, slot func(woundClass) woundClass) map[[numWoundClasses]int]float64 {
+	var slots [numWoundClasses][]float64
+	for class, n := range saveable {
+		if n == 0 {
+			continue
+		}
+		s := slot(woundClass(class))
+		unsaved := c[class].unsavedDist(n)
+		if slots[s] != nil {
+			unsaved = addConvolution(nil, slots[s], unsaved, 1.0)
+		}
+		slots[s] = unsaved
+	}
+
+	dist := map[[numWoundClasses]int]float64{{}: 1.0}
+	for s, unsaved := range slots {
+		if unsaved == nil {
+			continue
+		}
+		next := make(map[[numWoundClasses]int]float64, len(dist)*len(unsaved))
+		for counts, p := range dist {
+			for u, pU := range unsaved {
+				if pU == 0 {
 					continue
 				}
-				resolveDamageToSlice(
-					u, dw,
-					dmgDist, dmgDist,
-					woundsPerModel,
-					targetCount,
-					finalKilledSlice,
-					weight,
-				)
-				totalUnsaved := u + dw
-				// Lazily compute convolution only when needed
-				if damageConvs[totalUnsaved] == nil {
-					prev := damageConvs[totalUnsaved-1]
-					curr := make([]float64, len(prev)+maxD)
-					for i, pPrev := range prev {
-						for dVal, pD := range dmgDist {
-							curr[i+dVal] += pPrev * pD
-						}
-					}
-					damageConvs[totalUnsaved] = curr
-				}
+				counts[s] = u
+				next[counts] += p * pU
+			}
+		}
+		dist = next
+	}
+	return dist
+}
 
-				for d, pD := range damageConvs[totalUnsaved] {
-					totalDamageVec[d] += pD * weight
-				}
+// unsavedDist returns the PMF of unsaved wounds among n normal wounds.
+// With a single reroll, it counts the saved wounds instead: a failed save
+// saves nothing, so their pool is exactly kept^n + fresh ⊛ (all^n −
+// kept^n), where kept^n is the mass in which no save can use the reroll.
+func (r saveRolls) unsavedDist(n int) []float64 {
+	if !r.singleReroll || r.eligible <= 0 || n == 0 {
+		return getBinomialVector(n, r.probFailed)
+	}
+
+	probSaved := 1.0 - r.probFailed
+	keptMass := 1.0 - r.eligible
+	all := getBinomialVector(n, probSaved)
+	kept := getBinomialVector(n, probSaved/keptMass)
+	scale := powFloat(keptMass, n)
+
+	dist := make([]float64, n+1)
+	for saved := range all {
+		keptP := scale * kept[saved]
+		dist[n-saved] += keptP
+		if saved == n {
+			continue
+		}
+		rerolled := all[saved] - keptP
+		dist[n-saved] += rerolled * r.freshFailed
+		dist[n-saved-1] += rerolled * (1.0 - r.freshFailed)
+	}
+	return dist
+}
+
+// computeDamageAllocation resolves each (unsaved, devastating, mortal)
+// wound state against the target's initial wound states (see
+// targetLayout), returning the final wound states and the total damage
+// dealt. Each stream has its own per-wound damage PMF per model group (see
+// groupDamageDists). The damage PMF is only computed for a single group:
+// with mixed models it depends on allocation (see allocateDealt).
+func computeDamageAllocation(
+	initialStates []float64,
+	streamDist map[woundStreams]float64,
+	saves classSaves,
+	dists []groupDamage,
+	layout *targetLayout,
+) (finalStates, damageVec []float64) {
+	finalStates = make([]float64, len(initialStates))
+
+	unsaved := unsavedStreams(streamDist, saves.unsavedCounts)
+	keys, mortalWeights := allocationKeys(unsaved)
+	for _, k := range keys {
+		resolveDamageToSlice(
+			initialStates,
+			k.unsaved, k.devastating, mortalWeights[k],
+			dists, layout,
+			finalStates,
+		)
+	}
+
+	if len(dists) > 1 {
+		return finalStates, nil
+	}
+	if dists[0].hasSingleReroll() {
+		return finalStates, damageWithReroll(unsaved, dists[0])
+	}
+	dist := dists[0]
+
+	// Total damage is the sum of the streams: for each count of unsaved
+	// wounds, mix the devastating and mortal damage first, then convolve
+	// once with the damage of the unsaved wounds.
+	var classConvs [numWoundClasses]*damageConvolutions
+	for class := range classConvs {
+		classConvs[class] = newDamageConvolutions(dist.saveable[class].dist)
+	}
+	devConvs := newDamageConvolutions(dist.devastating.dist)
+	mortalConvs := newDamageConvolutions(dist.mortal)
+	otherMix := make(map[unsavedWounds][]float64)
+	var unsavedKeys []unsavedWounds
+	for _, s := range sortedWoundStreams(unsaved) {
+		k := unsavedWounds(s.Saveable)
+		if _, ok := otherMix[k]; !ok {
+			unsavedKeys = append(unsavedKeys, k)
+		}
+		otherMix[k] = addConvolution(otherMix[k],
+			devConvs.get(s.Devastating), mortalConvs.get(s.Mortal), unsaved[s])
+	}
+	var totalDamageVec []float64
+	for _, k := range unsavedKeys {
+		unsavedDmg := []float64{1.0}
+		for class, n := range k {
+			unsavedDmg = addConvolution(nil, unsavedDmg, classConvs[class].get(n), 1.0)
+		}
+		totalDamageVec = addConvolution(totalDamageVec, unsavedDmg, otherMix[k], 1.0)
+	}
+
+	return finalStates, totalDamageVec
+}
+
+// unsavedStreams returns the same streams with the saveable wounds of
+// each class replaced by the number that get through their saving throws,
+// as counted by unsaved (e.g. classSaves.unsavedCounts).
+func unsavedStreams(streamDist map[woundStreams]float64, unsaved func([numWoundClasses]int) map[[numWoundClasses]int]float64) map[woundStreams]float64 {
+	res := make(map[woundStreams]float64)
+	counted := make(map[[numWoundClasses]int]map[[numWoundClasses]int]float64)
+	for _, s := range sortedWoundStreams(streamDist) {
+		pJoint := streamDist[s]
+		if pJoint < coarseNegligibleProbability {
+			continue
+		}
+		dist, ok := counted[s.Saveable]
+		if !ok {
+			dist = unsaved(s.Saveable)
+			counted[s.Saveable] = dist
+		}
+		for counts, pU := range dist {
+			weight := pJoint * pU
+			if weight < negligibleProbability {
+				continue
 			}
+			res[woundStreams{Saveable: counts, Devastating: s.Devastating, Mortal: s.Mortal}] += weight
+		}
+	}
+	return res
+}
+
+// allocationKey is the unsaved and devastating wounds of an unsaved
+// stream count, which are allocated before its mortal wounds.
+type allocationKey struct {
+	unsaved     unsavedWounds
+	devastating int
+}
+
+// allocationKeys groups the unsaved streams by allocationKey, in a fixed
+// order. Mortal wounds are resolved last, so stream counts sharing a key
+// share the allocation of its damage: mortalWeights[k][m] is the weight
+// of the stream count with key k and m mortal wounds.
+func allocationKeys(unsaved map[woundStreams]float64) (keys []allocationKey, mortalWeights map[allocationKey][]float64) {
+	mortalWeights = make(map[allocationKey][]float64)
+	for _, s := range sortedWoundStreams(unsaved) {
+		k := allocationKey{s.Saveable, s.Devastating}
+		weights, ok := mortalWeights[k]
+		if !ok {
+			keys = append(keys, k)
+		}
+		for len(weights) <= s.Mortal {
+			weights = append(weights, 0)
+		}
+		weights[s.Mortal] += unsaved[s]
+		mortalWeights[k] = weights
+	}
+	return keys, mortalWeights
+}
+
+// unsavedWounds counts the unsaved wounds of each woundClass.
+type unsavedWounds [numWoundClasses]int
+
+// damageWithReroll returns the total damage PMF of the unsaved streams
+// when a single Damage reroll is available. The classes are resolved in
+// order, so for n_c unsaved wounds of class c, d devastating and m mortal
+// wounds it is
+//
+//	⊛_c kept_c^{n_c} ⊛ A_D^d ⊛ M^m
+//	  + Σ_j (⊛_{c<j} kept_c^{n_c} ⊛ (A_j^{n_j} − kept_j^{n_j}) ⊛ ⊛_{c>j} D_c^{n_c}) ⊛ D_D^d ⊛ M^m,
+//
+// where A^n is the damage of n wounds with the reroll available: either
+// no earlier wound spends it, or one does and the later wounds are
+// rolled plainly.
+func damageWithReroll(unsaved map[woundStreams]float64, dist groupDamage) []float64 {
+	var plainConvs, keptConvs [numWoundClasses]*damageConvolutions
+	var rerollConvs [numWoundClasses]*rerollConvolutions
+	for class, d := range dist.saveable {
+		plainConvs[class] = newDamageConvolutions(d.dist)
+		keptConvs[class] = newDamageConvolutions(d.kept)
+		rerollConvs[class] = newRerollConvolutions(d.kept, d.spent, plainConvs[class])
+	}
+	devConvs := newDamageConvolutions(dist.devastating.dist)
+	rerollDevConvs := newRerollConvolutions(dist.devastating.kept, dist.devastating.spent, devConvs)
+	mortalConvs := newDamageConvolutions(dist.mortal)
+
+	// availMix and spentMix mix the devastating and mortal damage for each
+	// count of unsaved wounds, with the reroll still available or already
+	// spent.
+	availMix := make(map[unsavedWounds][]float64)
+	spentMix := make(map[unsavedWounds][]float64)
+	var unsavedKeys []unsavedWounds
+	for _, s := range sortedWoundStreams(unsaved) {
+		k := unsavedWounds(s.Saveable)
+		if _, ok := availMix[k]; !ok {
+			unsavedKeys = append(unsavedKeys, k)
+		}
+		mortal := mortalConvs.get(s.Mortal)
+		availMix[k] = addConvolution(availMix[k], rerollDevConvs.get(s.Devastating), mortal, unsaved[s])
+		spentMix[k] = addConvolution(spentMix[k], devConvs.get(s.Devastating), mortal, unsaved[s])
+	}
+
+	var totalDamageVec []float64
+	for _, k := range unsavedKeys {
+		// plainSuffix[j] is the plain damage of the classes after j.
+		var plainSuffix [numWoundClasses][]float64
+		suffix := []float64{1.0}
+		for class := numWoundClasses - 1; class >= 0; class-- {
+			plainSuffix[class] = suffix
+			suffix = addConvolution(nil, suffix, plainConvs[class].get(k[class]), 1.0)
+		}
+
+		prefixAvail := []float64{1.0}
+		var prefixSpent []float64
+		for class, n := range k {
+			kept := keptConvs[class].get(n)
+			spending := subtractVector(rerollConvs[class].get(n), kept)
+			prefixSpent = addConvolution(prefixSpent,
+				addConvolution(nil, prefixAvail, spending, 1.0), plainSuffix[class], 1.0)
+			prefixAvail = addConvolution(nil, prefixAvail, kept, 1.0)
+		}
+
+		totalDamageVec = addConvolution(totalDamageVec, prefixAvail, availMix[k], 1.0)
+		totalDamageVec = addConvolution(totalDamageVec, prefixSpent, spentMix[k], 1.0)
+	}
+	return totalDamageVec
+}
+
+// subtractVector returns a − b, as long as the longer of the two.
+func subtractVector(a, b []float64) []float64 {
+	diff := make([]float64, max(len(a), len(b)))
+	for i, p := range a {
+		diff[i] += p
+	}
+	for i, p := range b {
+		diff[i] -= p
+	}
+	return diff
+}
+
+// rerollConvolutions lazily caches the damage PMF of n wounds rolled with
+// a single Damage reroll available: A^n = kept ⊛ A^(n−1) + spent ⊛
+// D^(n−1), where D^n are the plain convolutions.
+type rerollConvolutions struct {
+	kept, spent map[int]float64
+	plain       *damageConvolutions
+	convs       [][]float64
+}
+
+func newRerollConvolutions(kept, spent map[int]float64, plain *damageConvolutions) *rerollConvolutions {
+	return &rerollConvolutions{kept: kept, spent: spent, plain: plain, convs: [][]float64{{1.0}}}
+}
+
+func (c *rerollConvolutions) get(n int) []float64 {
+	for k := len(c.convs); k <= n; k++ {
+		var curr []float64
+		curr = addConvolution(curr, c.convs[k-1], vectorFromMap(c.kept), 1.0)
+		curr = addConvolution(curr, c.plain.get(k-1), vectorFromMap(c.spent), 1.0)
+		c.convs = append(c.convs, curr)
+	}
+	return c.convs[n]
+}
+
+// addConvolution adds weight * (a ⊛ b) to dest, growing it as needed.
+func addConvolution(dest, a, b []float64, weight float64) []float64 {
+	if need := len(a) + len(b) - 1; len(dest) < need {
+		dest = append(dest, make([]float64, need-len(dest))...)
+	}
+	for i, pA := range a {
+		if pA < negligibleProbability {
+			continue
+		}
+		for j, pB := range b {
+			dest[i+j] += weight * pA * pB
 		}
 	}
+	return dest
+}
 
-	return finalKilledSlice, totalDamageVec
+// damageConvolutions lazily caches the n-fold convolution of a per-wound
+// damage PMF: get(n) is the total damage PMF of n wounds.
+type damageConvolutions struct {
+	dmgDist map[int]float64
+	maxD    int
+	convs   [][]float64
+}
+
+func newDamageConvolutions(dmgDist map[int]float64) *damageConvolutions {
+	maxD := 0
+	for dVal := range dmgDist {
+		maxD = max(maxD, dVal)
+	}
+	return &damageConvolutions{dmgDist: dmgDist, maxD: maxD, convs: [][]float64{{1.0}}}
+}
+
+func (c *damageConvolutions) get(n int) []float64 {
+	for k := len(c.convs); k <= n; k++ {
+		prev := c.convs[k-1]
+		curr := make([]float64, len(prev)+c.maxD)
+		for i, pPrev := range prev {
+			for dVal, pD := range c.dmgDist {
+				curr[i+dVal] += pPrev * pD
+			}
+		}
+		c.convs = append(c.convs, curr)
+	}
+	return c.convs[n]
 }
 
 // computeHitOutcomeDist returns the PMF of hit outcomes for a single attack.
@@ -453,14 +1336,67 @@ func computeHitOutcomeDist(req CombatSimulationRequest) map[HitOutcome]float64 {
 		req.Attacker.BS,
 		req.Settings.HitReroll,
 		req.Settings.HitModifier,
-		req.Attacker.LethalHits,
-		req.Attacker.SustainedHits,
+		criticalHitEffects(req.Attacker),
 		req.Settings.CriticalHitThreshold,
 	)
 }
 
+// singleHitReroll is the effect of a single hit reroll on the pool of hit
+// rolls: eligible is the chance that a hit roll is a miss that no other
+// reroll applies to, and fresh is the outcome PMF of the rerolled die.
+type singleHitReroll struct {
+	eligible float64
+	fresh    map[HitOutcome]float64
+}
+
+// computeSingleHitReroll returns the single hit reroll of req, or nil if
+// it has none. Torrent attacks make no hit roll to reroll.
+func computeSingleHitReroll(req CombatSimulationRequest) *singleHitReroll {
+	if !req.Settings.SingleReroll.Hit || req.Attacker.Torrent {
+		return nil
+	}
+	eligible := singleHitRerollEligible(
+		req.Attacker.BS,
+		req.Settings.HitModifier,
+		req.Settings.CriticalHitThreshold,
+		req.Settings.HitReroll,
+	)
+	if eligible <= 0 {
+		return nil
+	}
+
+	// The rerolled die cannot be rerolled again.
+	fresh := req
+	fresh.Settings.HitReroll = RerollNone
+	return &singleHitReroll{eligible: eligible, fresh: computeHitOutcomeDist(fresh)}
+}
+
+// computeWoundOutcomeDist returns the PMF of wound outcomes for a single
+// successful hit.
+func computeWoundOutcomeDist(req CombatSimulationRequest) map[WoundOutcome]float64 {
+	return CalculateSingleWoundDistribution(
+		req.Attacker.Strength,
+		req.Target.Toughness,
+		req.Settings.WoundReroll,
+		req.Settings.WoundModifier,
+		req.Settings.CriticalWoundThreshold,
+		req.Attacker.Anti,
+		req.Target.Keywords,
+		req.Target.WoundRollsFailUpTo,
+	)
+}
+
 // --- HELPER FUNCTIONS ---
 
+// halfRangeBonus returns the X of a [MELTA X] or [RAPID FIRE X] ability when
+// the target is within half range, and 0 otherwise.
+func halfRangeBonus(x int, halfRange bool) int {
+	if !halfRange || x < 0 {
+		return 0
+	}
+	return x
+}
+
 // getBinomialVector calculates the binomial distribution for n trials with
 // probability p. Returns a vector where index i is the probability of
 // exactly i successes.
@@ -498,8 +1434,23 @@ func vectorToMap(vec []float64) map[int]float64 {
 	return res
 }
 
-// applyWoundsLinear is the core state-transition engine.
-func applyWoundsLinear(next, states []float64, dmgDist map[int]float64, maxHP int, spills bool) {
+// vectorFromMap is the inverse of vectorToMap.
+func vectorFromMap(dist map[int]float64) []float64 {
+	maxKey := 0
+	for k := range dist {
+		maxKey = max(maxKey, k)
+	}
+	vec := make([]float64, maxKey+1)
+	for k, p := range dist {
+		vec[k] = p
+	}
+	return vec
+}
+
+// applyWoundsLinear is the core state-transition engine: it applies one
+// wound to every state, with the damage PMF of the group whose model is
+// allocated to in that state (see targetLayout).
+func applyWoundsLinear(next, states []float64, dmgDists []map[int]float64, layout *targetLayout, spills bool) {
 	// 'next' must be zeroed by the caller before passing in.
 
 	for currentWounds, stateProb := range states {
@@ -511,33 +1462,16 @@ func applyWoundsLinear(next, states []float64, dmgDist map[int]float64, maxHP in
 			continue
 		}
 
-		for dVal, dProb := range dmgDist {
-			p := stateProb * dProb
-
-			var newWounds int
-			if spills {
-				// Mortal Wounds: Simple subtraction, floor at 0
-				newWounds = currentWounds - dVal
-				if newWounds < 0 {
-					newWounds = 0
-				}
-			} else {
-				// Normal Damage: Cannot lose more than the current model's remaining HP
-				// Current model HP is: ((currentWounds-1) % maxHP) + 1
-				currentModelHP := ((currentWounds - 1) % maxHP) + 1
-				damageDealt := dVal
-				if damageDealt > currentModelHP {
-					damageDealt = currentModelHP
-				}
-				newWounds = currentWounds - damageDealt
-			}
-			next[newWounds] += p
+		// Mortal Wounds spill over to the next model; normal damage
+		// cannot exceed the current model's remaining wounds.
+		for dVal, dProb := range dmgDists[layout.groupAt(currentWounds)] {
+			next[layout.afterDamage(currentWounds, dVal, spills)] += stateProb * dProb
 		}
 	}
 }
 
 // formatResponse calculates final averages and builds the structured response for the client.
-func formatResponse(hits, wounds, pens, damage, killed map[int]float64) SimulationResult {
+func formatResponse(hits, wounds, pens, damage, killed, mortals map[int]float64, points UnitPoints) SimulationResult {
 	avgK := 0.0
 	for k, v := range killed {
 		avgK += float64(k) * v
@@ -547,7 +1481,7 @@ func formatResponse(hits, wounds, pens, damage, killed map[int]float64) Simulati
 		avgH += float64(k) * v
 	}
 
-	return SimulationResult{
+	result := SimulationResult{
 		//mapping here
 		AverageHits:      avgH,
 		AverageDestroyed: avgK,
@@ -556,7 +1490,28 @@ func formatResponse(hits, wounds, pens, damage, killed map[int]float64) Simulati
 		PenDist:          pens,
 		DamageDist:       damage,
 		DestroyedDist:    killed,
+		MortalWoundDist:  mortals,
+		Stats: DistributionStats{
+			Hits:      summarizeDist(hits),
+			Wounds:    summarizeDist(wounds),
+			Pens:      summarizeDist(pens),
+			Damage:    summarizeDist(damage),
+			Destroyed: summarizeDist(killed),
+		},
 	}
+
+	// Points removed are a fixed multiple of the models destroyed.
+	if points.TargetPerModel > 0 {
+		result.PointsDestroyedDist = make(map[int]float64, len(killed))
+		for k, v := range killed {
+			result.PointsDestroyedDist[k*points.TargetPerModel] += v
+		}
+		result.ExpectedPointsDestroyed = avgK * float64(points.TargetPerModel)
+		if points.Attacker > 0 {
+			result.PointsPer100 = result.ExpectedPointsDestroyed * 100 / float64(points.Attacker)
+		}
+	}
+	return result
 }
 
 // Precompute all binomial distributions for n=0 to maxN with probability p
@@ -595,56 +1550,142 @@ func precomputeBinomials(maxN int, p float64) [][]float64 {
 	return res
 }
 
+// resolveDamageToSlice allocates the unsaved wounds of each class,
+// in class order, and nDev devastating wounds to a target in
+// initialStates, followed by the mortal wounds:
+// mortalWeights[m] is the weight of the outcome in which m mortal wounds
+// are inflicted afterwards. The weighted final states are added to dest.
 func resolveDamageToSlice(
-	nNorm, nMortal int,
-	normDmgDist, mortalDmgDist map[int]float64,
-	maxHP, totalModels int,
+	initialStates []float64,
+	unsaved unsavedWounds,
+	nDev int,
+	mortalWeights []float64,
+	dists []groupDamage,
+	layout *targetLayout,
 	dest []float64,
-	weight float64,
 ) {
-	maxPossible := totalModels * maxHP
+	var classDmgDists [numWoundClasses][]map[int]float64
+	devDmgDists := make([]map[int]float64, len(dists))
+	mortalDmgDists := make([]map[int]float64, len(dists))
+	for class := range classDmgDists {
+		classDmgDists[class] = make([]map[int]float64, len(dists))
+	}
+	for i, d := range dists {
+		devDmgDists[i], mortalDmgDists[i] = d.devastating.dist, d.mortal
+		for class := range classDmgDists {
+			classDmgDists[class][i] = d.saveable[class].dist
+		}
+	}
 
 	// Buffers for ping-ponging.
 	// To reach Zero-Alloc, these should be moved to a sync.Pool.
-	buf1 := make([]float64, maxPossible+1)
-	buf2 := make([]float64, maxPossible+1)
+	buf1 := make([]float64, len(initialStates))
+	buf2 := make([]float64, len(initialStates))
 
 	states := buf1
-	states[maxPossible] = 1.0
+	copy(states, initialStates)
 	next := buf2
 
-	// 1. Normal Hits Loop
-	for i := 0; i < nNorm; i++ {
-		// Zero the scratchpad
-		for j := range next {
-			next[j] = 0
+	if dists[0].hasSingleReroll() {
+		// 1-2. Saveable and devastating wounds, tracking the single
+		// reroll.
+		states = resolveWithSingleReroll(states, unsaved, nDev, dists, layout)
+	} else {
+		// 1. Saveable wounds, each class at its own Damage.
+		for class, n := range unsaved {
+			for i := 0; i < n; i++ {
+				// Zero the scratchpad
+				for j := range next {
+					next[j] = 0
+				}
+				// In-place mutation
+				applyWoundsLinear(next, states, classDmgDists[class], layout, false)
+				// Swap
+				states, next = next, states
+			}
 		}
-		// In-place mutation
-		applyWoundsLinear(next, states, normDmgDist, maxHP, false)
-		// Swap
-		states, next = next, states
-	}
 
-	// 2. Devastating Wounds Loop (spills = false by new rules)
-	for i := 0; i < nMortal; i++ {
-		for j := range next {
-			next[j] = 0
+		// 2. Devastating Wounds Loop (spills = false by new rules)
+		for i := 0; i < nDev; i++ {
+			for j := range next {
+				next[j] = 0
+			}
+			applyWoundsLinear(next, states, devDmgDists, layout, false)
+			states, next = next, states
 		}
-		applyWoundsLinear(next, states, mortalDmgDist, maxHP, false)
-		states, next = next, states
 	}
 
-	// 3. Accumulate weighted results directly into the destination
-	for remaining, prob := range states {
-		if prob < negligibleProbability {
+	// 3. Mortal Wounds Loop (spills = true). After m mortal wounds the
+	// state vector is accumulated with the weight of that outcome.
+	for m, weight := range mortalWeights {
+		if m > 0 {
+			for j := range next {
+				next[j] = 0
+			}
+			applyWoundsLinear(next, states, mortalDmgDists, layout, true)
+			states, next = next, states
+		}
+		if weight < negligibleProbability {
 			continue
 		}
-		modelsLeft := (remaining + maxHP - 1) / maxHP
-		killed := totalModels - modelsLeft
-		dest[killed] += prob * weight
+
+		// Accumulate weighted results directly into the destination
+		for remaining, prob := range states {
+			if prob < negligibleProbability {
+				continue
+			}
+			dest[remaining] += prob * weight
+		}
 	}
 }
 
+// resolveWithSingleReroll allocates the unsaved wounds of each class and
+// nDev devastating wounds to states while a single reroll is available
+// (see rolledDamage). The states are split on whether it has been spent yet; a
+// destroyed unit rolls no more damage, so its mass is simply moved to the
+// spent states.
+func resolveWithSingleReroll(states []float64, unsaved unsavedWounds, nDev int, dists []groupDamage, layout *targetLayout) []float64 {
+	avail := append([]float64(nil), states...)
+	spent := make([]float64, len(states))
+	nextAvail := make([]float64, len(states))
+	nextSpent := make([]float64, len(states))
+
+	full := make([]map[int]float64, len(dists))
+	kept := make([]map[int]float64, len(dists))
+	spending := make([]map[int]float64, len(dists))
+	step := func() {
+		spent[0] += avail[0]
+		avail[0] = 0
+		for j := range nextAvail {
+			nextAvail[j], nextSpent[j] = 0, 0
+		}
+		applyWoundsLinear(nextAvail, avail, kept, layout, false)
+		applyWoundsLinear(nextSpent, avail, spending, layout, false)
+		applyWoundsLinear(nextSpent, spent, full, layout, false)
+		avail, nextAvail = nextAvail, avail
+		spent, nextSpent = nextSpent, spent
+	}
+	resolve := func(n int, damage func(groupDamage) rolledDamage) {
+		for i, d := range dists {
+			r := damage(d)
+			full[i], kept[i], spending[i] = r.dist, r.kept, r.spent
+		}
+		for i := 0; i < n; i++ {
+			step()
+		}
+	}
+
+	for class, n := range unsaved {
+		resolve(n, func(d groupDamage) rolledDamage { return d.saveable[class] })
+	}
+	resolve(nDev, func(d groupDamage) rolledDamage { return d.devastating })
+
+	for j := range avail {
+		avail[j] += spent[j]
+	}
+	return avail
+}
+
 // Dense 2D probability mass for joint hit outcomes
 // Indexing: [normalHits][lethalHits]
 type JointHitProbabilityMatrix [][]float64
@@ -653,6 +1694,8 @@ type JointHitProbabilityMatrix [][]float64
 // Indexing: [autoWounds][normalHits]
 type AutoWoundNormalHitMatrix [][]float64
 
+// BuildSingleAttackHitMatrix lays the hit outcome PMF of a single attack
+// out as a dense [normalHits][lethalHits] matrix.
 func BuildSingleAttackHitMatrix(
 	hitOutcomePMF map[HitOutcome]float64,
 	maxNormalHitsPerAttack int,
@@ -665,7 +1708,9 @@ func BuildSingleAttackHitMatrix(
 	}
 
 	for outcome, probability := range hitOutcomePMF {
-		n := outcome.NormalHits
+		// Critical hits roll to wound like normal hits; profiles that
+		// track them take their wounds from the stream PMF instead.
+		n := outcome.NormalHits + outcome.CriticalHits
 		l := outcome.LethalHits
 		if n <= maxNormalHitsPerAttack && l <= maxLethalHitsPerAttack {
 			matrix[n][l] += probability
@@ -764,6 +1809,56 @@ func ComputeMultiAttackHitDistribution(
 	return result
 }
 
+// ComputeMultiAttackHitDistributionWithSingleReroll is
+// ComputeMultiAttackHitDistribution for a pool in which one miss may be
+// rerolled once more. keptAttackMatrix is singleAttackMatrix without the
+// misses that may use the reroll, and freshAttackMatrix is the outcome of
+// the rerolled die. A miss adds nothing to the pool, so the result is
+// exactly kept^N + fresh ⊛ (single^N − kept^N): either no attack can use
+// the reroll, or one of them does and only its own outcome changes.
+func ComputeMultiAttackHitDistributionWithSingleReroll(
+	singleAttackMatrix JointHitProbabilityMatrix,
+	keptAttackMatrix JointHitProbabilityMatrix,
+	freshAttackMatrix JointHitProbabilityMatrix,
+	attacks int,
+	maxNormalPerAttack int,
+	maxLethalPerAttack int,
+	globalMaxNormal int,
+	globalMaxLethal int,
+) JointHitProbabilityMatrix {
+
+	all := ComputeMultiAttackHitDistribution(singleAttackMatrix, attacks,
+		maxNormalPerAttack, maxLethalPerAttack, globalMaxNormal, globalMaxLethal)
+	kept := ComputeMultiAttackHitDistribution(keptAttackMatrix, attacks,
+		maxNormalPerAttack, maxLethalPerAttack, globalMaxNormal, globalMaxLethal)
+
+	// rerolled is single^N − kept^N: the pools in which some miss is
+	// rerolled, before its new outcome is added.
+	rerolled := make(JointHitProbabilityMatrix, globalMaxNormal+1)
+	for n := range rerolled {
+		rerolled[n] = make([]float64, globalMaxLethal+1)
+		for l := range rerolled[n] {
+			rerolled[n][l] = all[n][l] - kept[n][l]
+		}
+	}
+
+	result, _, _ := ConvolveJointHitMatricesBounded(
+		rerolled, freshAttackMatrix,
+		globalMaxNormal, globalMaxLethal,
+		maxNormalPerAttack, maxLethalPerAttack,
+		globalMaxNormal, globalMaxLethal,
+	)
+	for n := range result {
+		for l := range result[n] {
+			result[n][l] += kept[n][l]
+		}
+	}
+	return result
+}
+
+// CollapseLethalHitsIntoAutoWounds re-indexes a joint hit matrix by the
+// auto-wounds of Lethal Hits: every other hit, critical hits included,
+// still rolls to wound.
 func CollapseLethalHitsIntoAutoWounds(
 	hitMatrix JointHitProbabilityMatrix,
 	maxNormalHits int,
@@ -810,10 +1905,17 @@ func (d *DamageCalculatorImpl) Hydrate(req *CombatSimulationRequest) {
 	req.Settings.CriticalHitThreshold = fixThreshold(req.Settings.CriticalHitThreshold)
 	req.Settings.CriticalWoundThreshold = fixThreshold(req.Settings.CriticalWoundThreshold)
 
-	// 2. Ballistic Skill (Clamping [2, 6])
+	// 2. Ballistic Skill (Clamping [2, 6]). Weapons is copied first so the
+	// caller's profiles are never modified.
 	if !req.Attacker.Torrent {
 		req.Attacker.BS = clamp(req.Attacker.BS, 2, 6)
 	}
+	req.Weapons = append([]AttackerProfile(nil), req.Weapons...)
+	for i := range req.Weapons {
+		if !req.Weapons[i].Torrent {
+			req.Weapons[i].BS = clamp(req.Weapons[i].BS, 2, 6)
+		}
+	}
 
 	// 3. Save Floor (Min 2+)
 	if req.Target.Save < 2 {
@@ -826,69 +1928,230 @@ func (d *DamageCalculatorImpl) Hydrate(req *CombatSimulationRequest) {
 		req.Target.Invulnerable = &val
 	}
 
-	// 5. Infinite Logic / Target Count Resolution
+	// 5. Model Groups. Like Weapons, Groups is copied before clamping, and
+	// the unit size is the sum of the groups.
+	if len(req.Target.Groups) > 0 {
+		req.Target.Groups = append([]ModelGroup(nil), req.Target.Groups...)
+		count := 0
+		for i := range req.Target.Groups {
+			g := &req.Target.Groups[i]
+			if g.Save < 2 {
+				g.Save = 2
+			}
+			if g.Invulnerable != nil && *g.Invulnerable < 2 {
+				val := 2
+				g.Invulnerable = &val
+			}
+			count += g.Count
+		}
+		req.Target.Count = &count
+	}
+
+	// 6. Infinite Logic / Target Count Resolution
 	if req.Target.Count == nil {
-		// Calculate the ceiling for target resolution
-		maxAttacks := GetMaxFromDice(req.Attacker.Attacks) * req.Attacker.Count
-		count := maxAttacks
+		// Calculate the ceiling for target resolution across every profile
+		count := maxVolleyAttacks(req.profiles(), req.Settings)
 
 		// Enforce DOS cap
-		if count > 200 {
-			count = 200
-		}
+		count = min(count, maxResolvedTargetCount)
 		req.Target.Count = &count
 	}
 }
 
+// maxResolvedTargetCount caps the model count Hydrate resolves for a
+// target whose Count is not given.
+const maxResolvedTargetCount = 200
+
+// maxVolleyAttacks returns the most attacks the profiles can make with the
+// given settings, the most models they can destroy.
+func maxVolleyAttacks(profiles []AttackerProfile, settings SimulationSettings) int {
+	total := 0
+	for _, a := range profiles {
+		total += (max(GetMaxFromDice(a.Attacks), a.AttacksOptions.Minimum) +
+			halfRangeBonus(a.RapidFireX, settings.HalfRange)) * a.Count
+	}
+	return total
+}
+
+// complexityThreshold is the highest score the default validators accept.
+const complexityThreshold = 8_000_000
+
 // DefaultComplexityValidator implements the stress-test logic.
-// It is read-only and calculates the computational cost.
+// It is read-only and calculates the computational cost. Every profile of
+// the request is resolved on its own, so the request costs the sum of its
+// profiles, once per start state of a Target.WoundsRemainingDist.
 func DefaultComplexityValidator(req *CombatSimulationRequest) error {
-	const Threshold = 8_000_000
+	var total profileCost
+	for _, attacker := range req.profiles() {
+		total = total.add(profileComplexity(req, attacker))
+	}
+	total.score *= max(1, len(req.Target.WoundsRemainingDist))
+	return total.check()
+}
 
-	// 1. maxAttacks
-	baseAttacksPerModel := GetMaxFromDice(req.Attacker.Attacks)
+// DefaultSequenceComplexityValidator is DefaultComplexityValidator for a
+// hydrated SequenceRequest, which costs the sum of its activations. An
+// activation allocates its volleys from every state the target may be in,
+// so its allocation is charged once per state: every state of the target
+// after the first activation, its start states before.
+func DefaultSequenceComplexityValidator(req *SequenceRequest) error {
+	var total profileCost
+	for i, a := range req.Activations {
+		step := CombatSimulationRequest{Attacker: a.Attacker, Weapons: a.Weapons, Target: req.Target, Settings: a.Settings}
+		for _, attacker := range step.profiles() {
+			cost := profileComplexity(&step, attacker)
+			from := max(1, len(req.Target.WoundsRemainingDist))
+			if i > 0 {
+				from = cost.states + 1
+			}
+			cost.score += (from - 1) * cost.allocation
+			total = total.add(cost)
+		}
+	}
+	return total.check()
+}
+
+// profileCost is the estimated cost of resolving one or more profiles:
+// their attacks, hits, wound streams and the target's state space, and
+// the score the default validators compare against complexityThreshold.
+// allocation is the part of the score spent allocating the volleys from
+// a single state.
+type profileCost struct {
+	attacks, hits, states int
+	streams               int
+	allocation            int
+	score                 int
+}
+
+// check rejects a cost whose score exceeds complexityThreshold.
+func (c profileCost) check() error {
+	if c.score > complexityThreshold {
+		return fmt.Errorf(
+			"complexity overflow: score=%d > %d (A=%d H=%d S=%d W=%d)",
+			c.score, complexityThreshold, c.attacks, c.hits, c.states, c.streams,
+		)
+	}
+	return nil
+}
+
+func (c profileCost) add(o profileCost) profileCost {
+	return profileCost{
+		attacks:    c.attacks + o.attacks,
+		hits:       c.hits + o.hits,
+		states:     max(c.states, o.states),
+		streams:    c.streams + o.streams,
+		allocation: c.allocation + o.allocation,
+		score:      c.score + o.score,
+	}
+}
+
+// profileComplexity estimates the cost of resolving attacker against the
+// target of req.
+func profileComplexity(req *CombatSimulationRequest, attacker AttackerProfile) profileCost {
+	baseAttacksPerModel := max(GetMaxFromDice(attacker.Attacks), attacker.AttacksOptions.Minimum) +
+		halfRangeBonus(attacker.RapidFireX, req.Settings.HalfRange)
 
 	blastBonusPerModel := 0
-	if req.Attacker.Blast {
+	if attacker.Blast {
 		blastBonusPerModel = *req.Target.Count / 5
 	}
 
 	maxAttacks :=
-		req.Attacker.Count *
+		attacker.Count *
 			(baseAttacksPerModel + blastBonusPerModel)
 	targetCount := 0
 	if req.Target.Count != nil {
 		targetCount = *req.Target.Count
 	}
-	if req.Attacker.Blast {
+	if attacker.Blast {
 		maxAttacks += targetCount / 5
 	}
 
-	// 2. stateSpace
 	stateSpace := targetCount * req.Target.WoundsPerModel
+	if len(req.Target.Groups) > 0 {
+		// Precision keeps the Character's wounds on a track of their own,
+		// multiplying the states (see targetLayout).
+		mainWounds, characterWounds := 0, 0
+		for _, g := range req.Target.Groups {
+			designated := req.Target.PrecisionTarget == "" || req.Target.PrecisionTarget == g.Name
+			if attacker.Precision && g.Character && designated && characterWounds == 0 {
+				characterWounds = g.Count * g.WoundsPerModel
+				continue
+			}
+			mainWounds += g.Count * g.WoundsPerModel
+		}
+		stateSpace = (mainWounds+1)*(characterWounds+1) - 1
+	}
 
-	// 3. hit upper bound
-	maxHitsPerAttack := 1 + req.Attacker.SustainedHits
+	maxHitsPerAttack := 1 + attacker.SustainedHits
+	if d := attacker.SustainedHitsDice; d.Count > 0 && d.Sides > 0 {
+		maxHitsPerAttack += GetMaxFromDice(d)
+	}
 	maxHits := maxAttacks * maxHitsPerAttack
 
-	// 4. score
 	logA := 0
 	for a := maxAttacks; a > 1; a >>= 1 {
 		logA++
 	}
 
+	streams := woundStreamsComplexity(attacker, maxAttacks, maxHits)
+
+	allocation := 4 * maxHits * stateSpace
 	score :=
 		logA*maxHits*maxHits +
 			maxHits*maxHits +
-			4*maxHits*stateSpace
+			allocation +
+			streams/4
 
-	if score > Threshold {
-		return fmt.Errorf(
-			"complexity overflow: score=%d > %d (A=%d H=%d S=%d)",
-			score, Threshold, maxAttacks, maxHits, stateSpace,
-		)
+	return profileCost{
+		attacks: maxAttacks, hits: maxHits, states: stateSpace, streams: streams,
+		allocation: allocation, score: score,
 	}
-	return nil
+}
+
+// woundStreamsComplexity estimates the cost of the per-attack wound
+// streams (see volleyWoundStreams), which critical effects and mortal
+// wounds need: 0 if the profile has neither. Their volley PMF spans one
+// dimension per saveable woundClass and per devastating or mortal stream,
+// about C(A+d, d) entries, and each entry is thinned by the saving throws
+// into up to C(H+k, k) counts of the k damages the wounds can deal.
+func woundStreamsComplexity(attacker AttackerProfile, maxAttacks, maxHits int) int {
+	const limit = 1 << 30
+
+	tracksHits := criticalHitEffects(attacker).tracksCriticalHits()
+	tracksWounds := tracksCriticalWounds(attacker)
+	mortals := hasMortalWoundSource(attacker)
+	if !tracksHits && !tracksWounds && !mortals {
+		return 0
+	}
+
+	dims, damages := 1, 1
+	if tracksHits {
+		dims *= 2
+	}
+	if tracksWounds {
+		dims *= 2
+		damages++
+	}
+	if attacker.DevastatingWounds {
+		dims++
+	}
+	if mortals {
+		dims++
+	}
+	return binomialAtMost(maxAttacks+dims, dims, limit) * binomialAtMost(maxHits+damages, damages, limit)
+}
+
+// binomialAtMost returns C(n, k), or limit if it is larger.
+func binomialAtMost(n, k, limit int) int {
+	res := 1.0
+	for i := 1; i <= k; i++ {
+		res = res * float64(n-k+i) / float64(i)
+		if res > float64(limit) {
+			return limit
+		}
+	}
+	return int(math.Round(res))
 }
 
 // clamp helper for hydration efficiency
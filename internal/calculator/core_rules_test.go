@@ -1102,3 +1102,801 @@ func BenchmarkComplexityScaling(b *testing.B) {
 		})
 	}
 }
+
+func TestCalculateDamageCore_OptimalReroll(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	run := func(req CombatSimulationRequest, hit RerollType) SimulationResult {
+		req.Settings.HitReroll = hit
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*CombatSimulationRequest)
+		want   RerollType
+	}{
+		{
+			// Without critical-hit abilities, rerolling a hit is a loss.
+			name:   "Plain weapon rerolls failures",
+			modify: func(req *CombatSimulationRequest) {},
+			want:   RerollFail,
+		},
+		{
+			// S3 vs T8 wounds on a 6; Lethal Hits skip that roll.
+			name: "Lethal Hits against a tough target fish for criticals",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.Strength = 3
+				req.Attacker.LethalHits = true
+				req.Target.Toughness = 8
+			},
+			want: RerollNonCritical,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := generateBaseRequest()
+			tt.modify(&req)
+
+			optimal := run(req, RerollOptimal)
+			fail := run(req, RerollFail)
+			nonCrit := run(req, RerollNonCritical)
+			if math.Abs(fail.AverageDestroyed-nonCrit.AverageDestroyed) < epsilonCore {
+				t.Fatalf("test setup: policies should differ, both destroy %.6f", fail.AverageDestroyed)
+			}
+
+			want := fail
+			if tt.want == RerollNonCritical {
+				want = nonCrit
+			}
+			verifyValue(t, "AverageDestroyed", optimal.AverageDestroyed, want.AverageDestroyed)
+			verifyDist(t, "DestroyedDist", optimal.DestroyedDist, want.DestroyedDist)
+		})
+	}
+}
+
+func TestCalculateDamageCore_OptimalRerollAfterEarlierProfile(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// Against full 2-wound models this profile fishes for criticals, but
+	// against a model left on 1 wound it rerolls every failure.
+	fisher := AttackerProfile{
+		Count:         1,
+		Attacks:       DiceRoll{Modifier: 2},
+		BS:            3,
+		Strength:      4,
+		AP:            1,
+		Damage:        DiceRoll{Modifier: 1},
+		SustainedHits: 1,
+		LethalHits:    true,
+	}
+	// Torrent, S8 vs T4 and no save: 1 damage with probability 5/6, and
+	// no hit roll to reroll.
+	opener := AttackerProfile{
+		Count:    1,
+		Attacks:  DiceRoll{Modifier: 1},
+		Torrent:  true,
+		Strength: 8,
+		AP:       5,
+		Damage:   DiceRoll{Modifier: 1},
+	}
+
+	run := func(hit RerollType, profiles ...AttackerProfile) SimulationResult {
+		req := generateBaseRequest()
+		req.Attacker, req.Weapons = profiles[0], profiles[1:]
+		req.Target.Count = intPtr(3)
+		req.Settings.HitReroll = hit
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+
+	if alone, fishing := run(RerollOptimal, fisher), run(RerollNonCritical, fisher); math.Abs(alone.AverageDestroyed-fishing.AverageDestroyed) > epsilonCore {
+		t.Fatalf("test setup: alone, the profile should fish for criticals")
+	}
+
+	optimal := run(RerollOptimal, opener, fisher)
+	fail := run(RerollFail, opener, fisher)
+	fishing := run(RerollNonCritical, opener, fisher)
+	if fail.AverageDestroyed <= fishing.AverageDestroyed {
+		t.Fatalf("test setup: after the opener, rerolling failures should be better")
+	}
+	verifyValue(t, "AverageDestroyed", optimal.AverageDestroyed, fail.AverageDestroyed)
+	verifyDist(t, "DestroyedDist", optimal.DestroyedDist, fail.DestroyedDist)
+}
+
+func TestCalculateDamageCore_SingleReroll(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// Two rolls that each succeed half the time: with one reroll of a
+	// failure, both fail 1/4 · 1/2 and both succeed 1/4 + 1/2 · 1/2.
+	pooled := map[int]float64{0: 1.0 / 8.0, 1: 3.0 / 8.0, 2: 1.0 / 2.0}
+
+	tests := []struct {
+		name   string
+		modify func(*CombatSimulationRequest)
+		check  func(*testing.T, SimulationResult)
+		// defender marks a reroll made by the target.
+		defender bool
+	}{
+		{
+			name: "Hit",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.BS = 4
+				req.Settings.SingleReroll.Hit = true
+			},
+			check: func(t *testing.T, res SimulationResult) {
+				verifyDist(t, "HitDist", res.HitDist, pooled)
+			},
+		},
+		{
+			name: "Wound",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.Torrent = true
+				req.Settings.SingleReroll.Wound = true
+			},
+			check: func(t *testing.T, res SimulationResult) {
+				verifyDist(t, "WoundDist", res.WoundDist, pooled)
+			},
+		},
+		{
+			name: "Save",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.Torrent = true
+				req.Attacker.Strength = 8
+				req.Target.Toughness = 1
+				req.Target.Save = 4
+				req.Attacker.AP = 0
+				req.Settings.SingleReroll.Save = true
+			},
+			check: func(t *testing.T, res SimulationResult) {
+				// S8 vs T1 wounds on a 2+; each wound is saved half the
+				// time.
+				wounds := map[int]float64{0: 1.0 / 36.0, 1: 10.0 / 36.0, 2: 25.0 / 36.0}
+				verifyDist(t, "WoundDist", res.WoundDist, wounds)
+				verifyDist(t, "PenDist", res.PenDist, map[int]float64{
+					0: wounds[0] + wounds[1]*3.0/4.0 + wounds[2]*1.0/2.0,
+					1: wounds[1]*1.0/4.0 + wounds[2]*3.0/8.0,
+					2: wounds[2] * 1.0 / 8.0,
+				})
+			},
+			defender: true,
+		},
+		{
+			name: "Wound with Devastating Wounds and mortal wounds",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.Torrent = true
+				req.Attacker.DevastatingWounds = true
+				req.Attacker.MortalWoundsOn = MortalWoundsOnCriticalWound
+				req.Attacker.MortalWounds = DiceRoll{Modifier: 1}
+				req.Settings.SingleReroll.Wound = true
+			},
+			check: func(t *testing.T, res SimulationResult) {
+				verifyDist(t, "WoundDist", res.WoundDist, pooled)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := generateBaseRequest()
+			req.Attacker.Count = 1
+			tt.modify(&req)
+
+			res, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, res)
+
+			// Rerolling a failure can only help whoever rolls.
+			plain := req
+			plain.Settings.SingleReroll = SingleRerolls{}
+			base, err := calc.CalculateDamageCore(plain)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			gain := res.AverageDestroyed - base.AverageDestroyed
+			if tt.defender {
+				gain = -gain
+			}
+			if gain < -epsilonCore {
+				t.Errorf("AverageDestroyed: %.6f with the reroll, %.6f without", res.AverageDestroyed, base.AverageDestroyed)
+			}
+		})
+	}
+
+	t.Run("Damage", func(t *testing.T) {
+		req := generateBaseRequest()
+		req.Attacker.Count = 1
+		req.Attacker.Attacks = DiceRoll{Modifier: 1}
+		req.Attacker.Torrent = true
+		req.Attacker.Strength = 8
+		req.Attacker.AP = 6
+		req.Attacker.Damage = DiceRoll{Count: 1, Sides: 6}
+		req.Target = TargetProfile{Count: intPtr(1), Toughness: 1, Save: 2, WoundsPerModel: 20}
+		req.Settings.SingleReroll.Damage = true
+
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// S8 vs T1 wounds on a 2+ and AP6 beats any save; a 1-3 is
+		// rerolled.
+		verifyDist(t, "DamageDist", res.DamageDist, map[int]float64{
+			0: 1.0 / 6.0,
+			1: 5.0 / 72.0, 2: 5.0 / 72.0, 3: 5.0 / 72.0,
+			4: 5.0 / 24.0, 5: 5.0 / 24.0, 6: 5.0 / 24.0,
+		})
+
+		// With several wounds, the damage read off the wound states of a
+		// model group matches the damage PMF.
+		req.Attacker.Attacks = DiceRoll{Modifier: 3}
+		res, err = calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		grouped := req
+		grouped.Target.Groups = []ModelGroup{{Name: "Monster", Count: 1, WoundsPerModel: 20, Save: 2}}
+		groupRes, err := calc.CalculateDamageCore(grouped)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "DamageDist", groupRes.DamageDist, res.DamageDist)
+		verifyValue(t, "Sum(DamageDist)", sumDistribution(res.DamageDist), 1.0)
+	})
+
+	t.Run("Several profiles are rejected", func(t *testing.T) {
+		req := generateBaseRequest()
+		req.Attacker.Count = 1
+		req.Weapons = []AttackerProfile{req.Attacker}
+		req.Settings.SingleReroll.Hit = true
+
+		if _, err := calc.CalculateDamageCore(req); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+
+	t.Run("Save against Groups", func(t *testing.T) {
+		req := generateBaseRequest()
+		req.Attacker.Count = 2
+		req.Attacker.Torrent = true
+		req.Attacker.Strength = 8
+		req.Attacker.AP = 0
+		req.Target.Toughness = 1
+		req.Target.Save = 4
+		req.Target.Count = intPtr(5)
+		req.Settings.SingleReroll.Save = true
+		want, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Two groups of the same models are the same unit.
+		req.Target.Groups = []ModelGroup{
+			{Name: "Leader", Count: 1, WoundsPerModel: req.Target.WoundsPerModel, Save: 4},
+			{Name: "Troopers", Count: 4, WoundsPerModel: req.Target.WoundsPerModel, Save: 4},
+		}
+		got, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "DamageDist", got.DamageDist, want.DamageDist)
+		verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+
+		req.Settings.SingleReroll.Damage = true
+		if _, err := calc.CalculateDamageCore(req); err == nil {
+			t.Error("with a single Damage reroll: expected an error, got nil")
+		}
+	})
+}
+
+func TestCalculateDamageCore_DamageOptions(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	base := func() CombatSimulationRequest {
+		req := generateBaseRequest()
+		req.Attacker.Count = 1
+		req.Attacker.Attacks = DiceRoll{Modifier: 3}
+		req.Attacker.Damage = DiceRoll{Count: 1, Sides: 6}
+		req.Target.WoundsPerModel = 3
+		return req
+	}
+
+	t.Run("Minimum reaches the allocation", func(t *testing.T) {
+		// With a minimum of 3, every unsaved wound destroys a W3 model.
+		req := base()
+		req.Attacker.DamageOptions = DiceOptions{Minimum: 3}
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "DestroyedDist", res.DestroyedDist, res.PenDist)
+	})
+
+	t.Run("Rerolls and best-of raise the destroyed average", func(t *testing.T) {
+		plain, err := calc.CalculateDamageCore(base())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, opts := range []DiceOptions{
+			{Reroll: DiceRerollBelowAverage},
+			{Reroll: DiceRerollBelow, RerollBelow: 3},
+			{Reroll: DiceRerollSingle},
+			{BestOf: 2},
+		} {
+			req := base()
+			req.Attacker.DamageOptions = opts
+			res, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("%+v: unexpected error: %v", opts, err)
+			}
+			if res.AverageDestroyed <= plain.AverageDestroyed {
+				t.Errorf("%+v: AverageDestroyed %.4f, want above %.4f", opts, res.AverageDestroyed, plain.AverageDestroyed)
+			}
+		}
+	})
+
+	t.Run("Single reroll policy matches the shared single reroll", func(t *testing.T) {
+		shared := base()
+		shared.Settings.SingleReroll.Damage = true
+		want, err := calc.CalculateDamageCore(shared)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The policy belongs to the profile, so a second weapon keeps it.
+		req := base()
+		req.Attacker.Count = 0
+		weapon := base().Attacker
+		weapon.DamageOptions = DiceOptions{Reroll: DiceRerollSingle}
+		req.Weapons = []AttackerProfile{weapon}
+		got, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+	})
+}
+
+func TestCalculateDamageCore_CriticalHitEffects(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// One BS 2+ attack: 2-5 hit (4/6) and the 6 is a Critical Hit (1/6).
+	base := func() CombatSimulationRequest {
+		req := generateBaseRequest()
+		req.Attacker.Count = 1
+		req.Attacker.Attacks = DiceRoll{Modifier: 1}
+		req.Attacker.BS = 2
+		req.Attacker.AP = 0
+		req.Target.WoundsPerModel = 1
+		return req
+	}
+
+	t.Run("Sustained Hits D3", func(t *testing.T) {
+		req := base()
+		req.Attacker.SustainedHitsDice = DiceRoll{Count: 1, Sides: 3}
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "HitDist", res.HitDist, map[int]float64{
+			0: 1.0 / 6.0, 1: 4.0 / 6.0, 2: 1.0 / 18.0, 3: 1.0 / 18.0, 4: 1.0 / 18.0,
+		})
+		verifyValue(t, "AverageHits", res.AverageHits, 4.0/6.0+3.0/6.0)
+	})
+
+	t.Run("Critical Hit Strength", func(t *testing.T) {
+		// S4 wounds T8 on a 6+; the Critical Hit rolls at S8, on a 4+.
+		req := base()
+		req.Target.Toughness = 8
+		req.Attacker.CriticalHitStrength = 8
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "WoundDist", res.WoundDist, map[int]float64{
+			0: 1.0 - 7.0/36.0, 1: 7.0 / 36.0,
+		})
+	})
+
+	t.Run("Critical Hit AP bonus", func(t *testing.T) {
+		// A 3+ save fails on 1-2 at AP0, and on 1-4 at AP-2.
+		req := base()
+		req.Attacker.CriticalHitAPBonus = 2
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pen := 4.0/6.0*1.0/2.0*1.0/3.0 + 1.0/6.0*1.0/2.0*2.0/3.0
+		verifyDist(t, "PenDist", res.PenDist, map[int]float64{0: 1.0 - pen, 1: pen})
+		verifyDist(t, "DestroyedDist", res.DestroyedDist, map[int]float64{0: 1.0 - pen, 1: pen})
+	})
+
+	t.Run("Lethal Hits with an AP bonus", func(t *testing.T) {
+		req := base()
+		req.Attacker.LethalHits = true
+		req.Attacker.CriticalHitAPBonus = 2
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pen := 4.0/6.0*1.0/2.0*1.0/3.0 + 1.0/6.0*2.0/3.0
+		verifyDist(t, "PenDist", res.PenDist, map[int]float64{0: 1.0 - pen, 1: pen})
+	})
+
+	t.Run("An AP bonus that changes no save changes nothing", func(t *testing.T) {
+		// At AP-3 the 4+ invulnerable save is taken either way, so the
+		// critical hit wounds only take a separate path to the same result.
+		plain := base()
+		plain.Attacker.Attacks = DiceRoll{Modifier: 4}
+		plain.Attacker.Damage = DiceRoll{Count: 1, Sides: 3}
+		plain.Attacker.AP = 3
+		plain.Attacker.SustainedHits = 1
+		plain.Target.Invulnerable = intPtr(4)
+		plain.Target.WoundsPerModel = 3
+		plain.Target.Count = intPtr(3)
+		plain.Settings.SingleReroll.Damage = true
+
+		bonus := plain
+		bonus.Attacker.CriticalHitAPBonus = 1
+
+		want, err := calc.CalculateDamageCore(plain)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := calc.CalculateDamageCore(bonus)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "PenDist", got.PenDist, want.PenDist)
+		verifyDist(t, "DamageDist", got.DamageDist, want.DamageDist)
+		verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+	})
+
+	t.Run("Groups fold the critical hit save like the unit save", func(t *testing.T) {
+		// A single group of the same models must match the plain target,
+		// with and without a single Damage reroll.
+		for _, damageReroll := range []bool{false, true} {
+			plain := base()
+			plain.Attacker.Attacks = DiceRoll{Modifier: 4}
+			plain.Attacker.Damage = DiceRoll{Count: 1, Sides: 3}
+			plain.Attacker.SustainedHits = 1
+			plain.Attacker.CriticalHitAPBonus = 2
+			plain.Target.WoundsPerModel = 3
+			plain.Target.Count = intPtr(3)
+			plain.Settings.SingleReroll.Damage = damageReroll
+
+			grouped := plain
+			grouped.Target.Groups = []ModelGroup{{Count: 3, WoundsPerModel: 3, Save: 3}}
+
+			want, err := calc.CalculateDamageCore(plain)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := calc.CalculateDamageCore(grouped)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+		}
+	})
+}
+
+func TestCalculateDamageCore_CriticalWoundEffects(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// One auto-hitting S4 attack against T4: 4-5 wound (1/3) and the 6 is a
+	// Critical Wound (1/6). A 3+ save fails on 1-2 at AP0.
+	base := func() CombatSimulationRequest {
+		req := generateBaseRequest()
+		req.Attacker.Count = 1
+		req.Attacker.Attacks = DiceRoll{Modifier: 1}
+		req.Attacker.Torrent = true
+		req.Attacker.AP = 0
+		return req
+	}
+
+	t.Run("Critical Wound AP bonus", func(t *testing.T) {
+		req := base()
+		req.Attacker.CriticalWoundAPBonus = 2
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pen := 1.0/3.0*1.0/3.0 + 1.0/6.0*2.0/3.0
+		verifyDist(t, "PenDist", res.PenDist, map[int]float64{0: 1.0 - pen, 1: pen})
+	})
+
+	t.Run("Critical Wound Damage bonus", func(t *testing.T) {
+		req := base()
+		req.Attacker.CriticalWoundDamageBonus = 1
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "DamageDist", res.DamageDist, map[int]float64{
+			0: 1.0 - 1.0/9.0 - 1.0/18.0, 1: 1.0 / 9.0, 2: 1.0 / 18.0,
+		})
+		verifyDist(t, "DestroyedDist", res.DestroyedDist, map[int]float64{0: 1.0 - 1.0/18.0, 1: 1.0 / 18.0})
+	})
+
+	t.Run("Devastating wounds take the Damage bonus", func(t *testing.T) {
+		req := base()
+		req.Attacker.DevastatingWounds = true
+		req.Attacker.CriticalWoundDamageBonus = 1
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "DamageDist", res.DamageDist, map[int]float64{
+			0: 1.0 - 1.0/9.0 - 1.0/6.0, 1: 1.0 / 9.0, 2: 1.0 / 6.0,
+		})
+	})
+
+	t.Run("Stacks with the Critical Hit AP bonus", func(t *testing.T) {
+		// BS 2+: a 6 to hit and a 6 to wound improve AP by 2, either alone
+		// by 1. A 3+ save fails on 1-3 at AP-1 and on 1-4 at AP-2.
+		req := base()
+		req.Attacker.Torrent = false
+		req.Attacker.BS = 2
+		req.Attacker.CriticalHitAPBonus = 1
+		req.Attacker.CriticalWoundAPBonus = 1
+		res, err := calc.CalculateDamageCore(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pen := 4.0/6.0*(1.0/3.0*1.0/3.0+1.0/6.0*1.0/2.0) + 1.0/6.0*(1.0/3.0*1.0/2.0+1.0/6.0*2.0/3.0)
+		verifyDist(t, "PenDist", res.PenDist, map[int]float64{0: 1.0 - pen, 1: pen})
+	})
+
+	t.Run("AP bonuses that change no save change nothing", func(t *testing.T) {
+		// At AP-3 the 4+ invulnerable save is taken either way, so every
+		// class of wound only takes a separate path to the same result.
+		plain := base()
+		plain.Attacker.Torrent = false
+		plain.Attacker.BS = 2
+		plain.Attacker.Attacks = DiceRoll{Modifier: 4}
+		plain.Attacker.Damage = DiceRoll{Count: 1, Sides: 3}
+		plain.Attacker.AP = 3
+		plain.Attacker.LethalHits = true
+		plain.Target.Invulnerable = intPtr(4)
+		plain.Target.WoundsPerModel = 3
+		plain.Target.Count = intPtr(3)
+		plain.Settings.SingleReroll.Damage = true
+
+		bonus := plain
+		bonus.Attacker.CriticalHitAPBonus = 1
+		bonus.Attacker.CriticalWoundAPBonus = 1
+
+		want, err := calc.CalculateDamageCore(plain)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := calc.CalculateDamageCore(bonus)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verifyDist(t, "PenDist", got.PenDist, want.PenDist)
+		verifyDist(t, "DamageDist", got.DamageDist, want.DamageDist)
+		verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+	})
+
+	t.Run("Groups fold the critical wound save and damage", func(t *testing.T) {
+		for _, damageReroll := range []bool{false, true} {
+			plain := base()
+			plain.Attacker.Torrent = false
+			plain.Attacker.BS = 2
+			plain.Attacker.Attacks = DiceRoll{Modifier: 4}
+			plain.Attacker.Damage = DiceRoll{Count: 1, Sides: 3}
+			plain.Attacker.CriticalHitAPBonus = 1
+			plain.Attacker.CriticalWoundAPBonus = 1
+			plain.Attacker.CriticalWoundDamageBonus = 1
+			plain.Target.WoundsPerModel = 3
+			plain.Target.Count = intPtr(3)
+			plain.Settings.SingleReroll.Damage = damageReroll
+
+			grouped := plain
+			grouped.Target.Groups = []ModelGroup{{Count: 3, WoundsPerModel: 3, Save: 3}}
+
+			want, err := calc.CalculateDamageCore(plain)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := calc.CalculateDamageCore(grouped)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+		}
+	})
+}
+
+func TestCalculateDamageCore_Overwatch(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	tests := []struct {
+		name     string
+		modify   func(*CombatSimulationRequest)
+		wantHits float64 // average hits of 20 attacks
+	}{
+		{"Only 6s hit", func(r *CombatSimulationRequest) {}, 20.0 / 6.0},
+		{"Modifiers don't help", func(r *CombatSimulationRequest) { r.Settings.HitModifier = 1 }, 20.0 / 6.0},
+		{"Critical Hits on 5+ hit on 5+", func(r *CombatSimulationRequest) { r.Settings.CriticalHitThreshold = 5 }, 20.0 / 3.0},
+		{"Failed hits are rerolled", func(r *CombatSimulationRequest) { r.Settings.HitReroll = RerollFail }, 20.0 * 11.0 / 36.0},
+		{"Torrent still hits automatically", func(r *CombatSimulationRequest) { r.Attacker.Torrent = true }, 20.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := generateBaseRequest()
+			req.Settings.FiringMode = FiringModeOverwatch
+			tt.modify(&req)
+			res, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			verifyValue(t, "AverageHits", res.AverageHits, tt.wantHits)
+		})
+	}
+}
+
+func TestCalculateDamageCore_RemainingWounds(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// Torrent, S8 vs T4, no save: one attack deals 1 damage with
+	// probability 5/6, to two 2-wound models of which one is wounded.
+	req := generateBaseRequest()
+	req.Attacker.Count = 1
+	req.Attacker.Attacks = DiceRoll{Modifier: 1}
+	req.Attacker.Torrent = true
+	req.Attacker.Strength = 8
+	req.Attacker.AP = 5
+	req.Target.Count = intPtr(2)
+	req.Target.WoundsPerModel = 2
+	req.Target.WoundsRemaining = intPtr(3)
+
+	res, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyDist(t, "DestroyedDist", res.DestroyedDist, map[int]float64{0: 1.0 / 6.0, 1: 5.0 / 6.0})
+	verifyDist(t, "RemainingWoundsDist", res.RemainingWoundsDist, map[int]float64{2: 5.0 / 6.0, 3: 1.0 / 6.0})
+	verifyDist(t, "WoundedModelDist", res.WoundedModelDist, map[int]float64{1: 1.0 / 6.0, 2: 5.0 / 6.0})
+	verifyValue(t, "WipeProb", res.WipeProb, 0)
+
+	req.Target.WoundsRemaining = intPtr(5)
+	if _, err := calc.CalculateDamageCore(req); err == nil {
+		t.Error("expected an error for more wounds remaining than the unit has")
+	}
+}
+
+func TestCalculateDamageCore_BlastAgainstDamagedTarget(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// One Blast attack at BS3+ against a unit of ten 1-wound models: +2
+	// attacks for the full unit, none once it is down to 3 models.
+	req := generateBaseRequest()
+	req.Attacker.Count = 1
+	req.Attacker.Attacks = DiceRoll{Modifier: 1}
+	req.Attacker.Blast = true
+	req.Target.Count = intPtr(10)
+	req.Target.WoundsPerModel = 1
+
+	tests := []struct {
+		name     string
+		setup    func(*TargetProfile)
+		wantHits float64
+	}{
+		{"Undamaged", func(*TargetProfile) {}, 3 * 2.0 / 3.0},
+		{"WoundsRemaining", func(t *TargetProfile) { t.WoundsRemaining = intPtr(3) }, 2.0 / 3.0},
+		{"WoundsRemainingDist", func(t *TargetProfile) {
+			t.WoundsRemainingDist = map[int]float64{3: 0.5, 10: 0.5}
+		}, 0.5*2.0/3.0 + 0.5*2.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := req
+			tt.setup(&r.Target)
+			res, err := calc.CalculateDamageCore(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			verifyValue(t, "AverageHits", res.AverageHits, tt.wantHits)
+		})
+	}
+}
+
+func TestCalculateDamageCore_WoundsRemainingDist(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// The attack above, against a unit already destroyed, left with 3
+	// wounds or undamaged.
+	req := generateBaseRequest()
+	req.Attacker.Count = 1
+	req.Attacker.Attacks = DiceRoll{Modifier: 1}
+	req.Attacker.Torrent = true
+	req.Attacker.Strength = 8
+	req.Attacker.AP = 5
+	req.Target.Count = intPtr(2)
+	req.Target.WoundsPerModel = 2
+	req.Target.WoundsRemainingDist = map[int]float64{0: 0.2, 3: 0.5, 4: 0.3}
+
+	res, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyDist(t, "DestroyedDist", res.DestroyedDist, map[int]float64{0: 0.2 + 0.5/6.0 + 0.3, 1: 0.5 * 5.0 / 6.0})
+	verifyDist(t, "RemainingWoundsDist", res.RemainingWoundsDist, map[int]float64{
+		0: 0.2, 2: 0.5 * 5.0 / 6.0, 3: 0.5/6.0 + 0.3*5.0/6.0, 4: 0.3 / 6.0,
+	})
+	verifyValue(t, "WipeProb", res.WipeProb, 0.2)
+	verifyValue(t, "AverageDestroyed", res.AverageDestroyed, 0.5*5.0/6.0)
+	verifyValue(t, "Stats.Destroyed.Mean", res.Stats.Destroyed.Mean, 0.5*5.0/6.0)
+
+	// A single start state is the same as WoundsRemaining.
+	req.Target.WoundsRemainingDist = map[int]float64{3: 1}
+	res, err = calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Target.WoundsRemainingDist = nil
+	req.Target.WoundsRemaining = intPtr(3)
+	want, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyDist(t, "single start DestroyedDist", res.DestroyedDist, want.DestroyedDist)
+	verifyDist(t, "single start WoundedModelDist", res.WoundedModelDist, want.WoundedModelDist)
+
+	req.Target.WoundsRemainingDist = map[int]float64{3: 1}
+	if _, err := calc.CalculateDamageCore(req); err == nil {
+		t.Error("expected an error for both WoundsRemaining and its distribution")
+	}
+	req.Target.WoundsRemaining = nil
+	req.Target.WoundsRemainingDist = map[int]float64{5: 1}
+	if _, err := calc.CalculateDamageCore(req); err == nil {
+		t.Error("expected an error for more wounds remaining than the unit has")
+	}
+}
+
+func TestCalculateDamageCore_Points(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// The same single attack as above, against one of two full-wound
+	// models worth 20 points each, fired by a 40-point unit.
+	req := generateBaseRequest()
+	req.Attacker.Count = 1
+	req.Attacker.Attacks = DiceRoll{Modifier: 1}
+	req.Attacker.Torrent = true
+	req.Attacker.Strength = 8
+	req.Attacker.AP = 5
+	req.Target.Count = intPtr(2)
+	req.Target.WoundsPerModel = 1
+	req.Points = UnitPoints{Attacker: 40, TargetPerModel: 20}
+
+	res, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyDist(t, "PointsDestroyedDist", res.PointsDestroyedDist, map[int]float64{0: 1.0 / 6.0, 20: 5.0 / 6.0})
+	verifyValue(t, "ExpectedPointsDestroyed", res.ExpectedPointsDestroyed, 20*5.0/6.0)
+	verifyValue(t, "PointsPer100", res.PointsPer100, 20*5.0/6.0*100/40)
+
+	// Without a target cost there is nothing to report; without an
+	// attacker cost there is no efficiency.
+	req.Points = UnitPoints{Attacker: 40}
+	if res, _ = calc.CalculateDamageCore(req); res.PointsDestroyedDist != nil || res.ExpectedPointsDestroyed != 0 {
+		t.Errorf("expected no points results, got %v", res.PointsDestroyedDist)
+	}
+	req.Points = UnitPoints{TargetPerModel: 20}
+	if res, _ = calc.CalculateDamageCore(req); res.PointsPer100 != 0 {
+		t.Errorf("expected no efficiency without attacker points, got %f", res.PointsPer100)
+	}
+}
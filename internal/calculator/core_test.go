@@ -21,6 +21,7 @@
 package calculator
 
 import (
+	"fmt"
 	"math"
 	"testing"
 )
@@ -490,24 +491,303 @@ func TestCalculateDamageCore_FeelNoPain_AppliedToDevastatingWounds(t *testing.T)
 	verifyDist(t, "DamageDist", resp.DamageDist, expectedDamageDist)
 }
 
+func TestCalculateDamageCore_FeelNoPain_ExcludesDevastatingWounds(t *testing.T) {
+	// Same setup as the test above, but the general FNP does not apply to
+	// devastating damage and the mortal-only FNP never does: the 1/6
+	// devastating branch always deals the full 4 damage, the 4/6 normal
+	// branch still rolls Binomial(4, 2/3) and the 1/6 failed wound deals 0.
+	req := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:             1,
+			Attacks:           DiceRoll{Modifier: 1},
+			Torrent:           true,
+			Strength:          8,
+			DevastatingWounds: true,
+			Damage:            DiceRoll{Modifier: 4},
+		},
+		Target: TargetProfile{
+			Count:                         intPtr(1),
+			Toughness:                     4,
+			Save:                          7,
+			WoundsPerModel:                10,
+			FeelNoPain:                    intPtr(5),
+			MortalFeelNoPain:              intPtr(2),
+			FeelNoPainExcludesDevastating: true,
+		},
+	}
+
+	miss := 1.0 / 6.0
+	normal := 4.0 / 6.0
+	dev := 1.0 / 6.0
+	expectedDamageDist := map[int]float64{
+		0: miss + normal*1.0/81.0,
+		1: normal * 8.0 / 81.0,
+		2: normal * 24.0 / 81.0,
+		3: normal * 32.0 / 81.0,
+		4: normal*16.0/81.0 + dev,
+	}
+
+	calc := &DamageCalculatorImpl{}
+	resp, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifyDist(t, "DamageDist", resp.DamageDist, expectedDamageDist)
+}
+
+func TestCalculateDamageCore_DamageReduction_Devastating(t *testing.T) {
+	// Torrent, S8 vs T4 and Save 7+: 1/6 fail, 4/6 normal, 1/6 devastating.
+	// Damage 3 with -1 Damage deals 2 per normal wound; the devastating
+	// wound keeps its full 3 only when the reduction excludes it.
+	base := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:             1,
+			Attacks:           DiceRoll{Modifier: 1},
+			Torrent:           true,
+			Strength:          8,
+			DevastatingWounds: true,
+			Damage:            DiceRoll{Modifier: 3},
+		},
+		Target: TargetProfile{
+			Count:           intPtr(1),
+			Toughness:       4,
+			Save:            7,
+			WoundsPerModel:  10,
+			DamageReduction: 1,
+		},
+	}
+
+	tests := []struct {
+		name                string
+		excludesDevastating bool
+		expectedDamageDist  map[int]float64
+	}{
+		{
+			name:               "Reduction applies to devastating wounds",
+			expectedDamageDist: map[int]float64{0: 1.0 / 6.0, 2: 5.0 / 6.0},
+		},
+		{
+			name:                "Reduction excludes devastating wounds",
+			excludesDevastating: true,
+			expectedDamageDist:  map[int]float64{0: 1.0 / 6.0, 2: 4.0 / 6.0, 3: 1.0 / 6.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base
+			req.Target.DamageReductionExcludesDevastating = tt.excludesDevastating
+
+			calc := &DamageCalculatorImpl{}
+			resp, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			verifyDist(t, "DamageDist", resp.DamageDist, tt.expectedDamageDist)
+		})
+	}
+}
+
+func TestCalculateDamageCore_MultipleWeapons(t *testing.T) {
+	// Two Torrent S8 D1 profiles against a single 2-wound model with an
+	// impossible save: each wounds on 2+ (5/6). Alone, neither can destroy
+	// the model; fired together, the second finishes off what the first
+	// wounded.
+	weapon := AttackerProfile{
+		Count:    1,
+		Attacks:  DiceRoll{Modifier: 1},
+		Torrent:  true,
+		Strength: 8,
+		Damage:   DiceRoll{Modifier: 1},
+	}
+	weapons := []AttackerProfile{weapon}
+	req := CombatSimulationRequest{
+		Attacker: weapon,
+		Weapons:  weapons,
+		Target: TargetProfile{
+			Count:          intPtr(1),
+			Toughness:      4,
+			Save:           7,
+			WoundsPerModel: 2,
+		},
+	}
+
+	calc := &DamageCalculatorImpl{}
+	resp, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifyDist(t, "HitDist", resp.HitDist, map[int]float64{2: 1.0})
+	verifyDist(t, "DamageDist", resp.DamageDist, map[int]float64{
+		0: 1.0 / 36.0,
+		1: 10.0 / 36.0,
+		2: 25.0 / 36.0,
+	})
+	verifyDist(t, "DestroyedDist", resp.DestroyedDist, map[int]float64{
+		0: 11.0 / 36.0,
+		1: 25.0 / 36.0,
+	})
+	verifyValue(t, "AverageDestroyed", resp.AverageDestroyed, 25.0/36.0)
+
+	if len(resp.Weapons) != 2 {
+		t.Fatalf("expected 2 per-weapon results, got %d", len(resp.Weapons))
+	}
+	for i, w := range resp.Weapons {
+		verifyDist(t, fmt.Sprintf("Weapons[%d].DamageDist", i), w.DamageDist, map[int]float64{0: 1.0 / 6.0, 1: 5.0 / 6.0})
+		verifyDist(t, fmt.Sprintf("Weapons[%d].DestroyedDist", i), w.DestroyedDist, map[int]float64{0: 1.0})
+	}
+
+	// Hydrate must not write through to the caller's slice.
+	weapons[0].BS = 1
+	if _, err := calc.CalculateDamageCore(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if weapons[0].BS != 1 {
+		t.Errorf("caller's weapon profile was modified: BS=%d", weapons[0].BS)
+	}
+}
+
+func TestCalculateDamageCore_SingleWeaponHasNoBreakdown(t *testing.T) {
+	req := generateBaseRequest()
+	calc := &DamageCalculatorImpl{}
+	resp, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Weapons != nil {
+		t.Errorf("expected no per-weapon breakdown, got %d entries", len(resp.Weapons))
+	}
+}
+
+func TestCalculateDamageCore_MeltaRapidFire_HalfRange(t *testing.T) {
+	// Torrent removes the hit roll, S8 vs T4 wounds on 2+ and Save 7+ can
+	// never pass, so only the attack count and the damage roll vary.
+	// Rapid Fire 1 doubles the single attack and Melta 2 turns D1 into D3,
+	// but only when the target is within half range.
+	base := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:      1,
+			Attacks:    DiceRoll{Modifier: 1},
+			Torrent:    true,
+			Strength:   8,
+			Damage:     DiceRoll{Modifier: 1},
+			MeltaX:     2,
+			RapidFireX: 1,
+		},
+		Target: TargetProfile{
+			Count:          intPtr(1),
+			Toughness:      4,
+			Save:           7,
+			WoundsPerModel: 10,
+		},
+	}
+
+	tests := []struct {
+		name               string
+		halfRange          bool
+		expectedHitsDist   map[int]float64
+		expectedDamageDist map[int]float64
+	}{
+		{
+			name:             "Outside half range",
+			halfRange:        false,
+			expectedHitsDist: map[int]float64{1: 1.0},
+			expectedDamageDist: map[int]float64{
+				0: 1.0 / 6.0,
+				1: 5.0 / 6.0,
+			},
+		},
+		{
+			name:             "Within half range",
+			halfRange:        true,
+			expectedHitsDist: map[int]float64{2: 1.0},
+			expectedDamageDist: map[int]float64{
+				0: 1.0 / 36.0,
+				3: 10.0 / 36.0,
+				6: 25.0 / 36.0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base
+			req.Settings.HalfRange = tt.halfRange
+
+			calc := &DamageCalculatorImpl{}
+			resp, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			verifyDist(t, "HitDist", resp.HitDist, tt.expectedHitsDist)
+			verifyDist(t, "DamageDist", resp.DamageDist, tt.expectedDamageDist)
+		})
+	}
+}
+
+func TestClassSaves_UnsavedByDamage(t *testing.T) {
+	saves := classSaves{{probFailed: 0.5}, {probFailed: 2.0 / 3.0}, {probFailed: 1.0 / 3.0}, {probFailed: 5.0 / 6.0}}
+	saveable := [numWoundClasses]int{2, 1, 3, 2}
+
+	// The joint PMF of every class, summed by the class of its damage.
+	want := make(map[[numWoundClasses]int]float64)
+	for counts, p := range saves.unsavedCounts(saveable) {
+		var merged [numWoundClasses]int
+		for class, n := range counts {
+			merged[woundClass(class).damageClass()] += n
+		}
+		want[merged] += p
+	}
+
+	got := saves.unsavedByDamage(saveable)
+	if len(got) != len(want) {
+		t.Fatalf("got %d counts, want %d", len(got), len(want))
+	}
+	for counts, p := range want {
+		if math.Abs(got[counts]-p) > epsilonCore {
+			t.Errorf("%v: got %.6f, want %.6f", counts, got[counts], p)
+		}
+	}
+}
+
 func TestComputeDamageAllocation(t *testing.T) {
 	// Certain: 1 normal wound before save, save always fails (probSaveFailed=1.0).
 	// Fixed 2 damage vs a single 5-wound model: never kills outright, always
 	// deals exactly 2 damage.
-	jointWoundDist := NormalDevastatingWoundMatrix{
-		{0, 0},
-		{1.0, 0},
-	}
-	maxHits := 1
+	streamDist := map[woundStreams]float64{{Saveable: [numWoundClasses]int{1}}: 1.0}
 
-	killed, damageVec := computeDamageAllocation(
-		jointWoundDist, maxHits, 1.0,
-		DiceRoll{Modifier: 2}, nil,
-		5, 1,
+	layout, err := newTargetLayout(TargetProfile{Count: intPtr(1), WoundsPerModel: 5}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	states, damageVec := computeDamageAllocation(
+		layout.initialStates(),
+		streamDist, classSaves{{probFailed: 1.0}},
+		[]groupDamage{{
+			saveable:    [numWoundClasses]rolledDamage{{dist: map[int]float64{2: 1.0}}},
+			devastating: rolledDamage{dist: map[int]float64{2: 1.0}},
+			mortal:      map[int]float64{1: 1.0},
+		}},
+		layout,
 	)
+	killed := layout.killed(states)
+
+	wantStates := []float64{0, 0, 0, 1.0, 0, 0} // 3 wounds left, with certainty
+	wantKilled := []float64{1.0, 0}             // 0 models destroyed, with certainty
+	wantDamage := []float64{0, 0, 1.0}          // 2 total damage, with certainty
 
-	wantKilled := []float64{1.0, 0}    // 0 models destroyed, with certainty
-	wantDamage := []float64{0, 0, 1.0} // 2 total damage, with certainty
+	if len(states) != len(wantStates) {
+		t.Fatalf("states: got %d entries, want %d", len(states), len(wantStates))
+	}
+	for i := range wantStates {
+		if math.Abs(states[i]-wantStates[i]) > epsilonCore {
+			t.Errorf("states[%d]: got %v, want %v", i, states[i], wantStates[i])
+		}
+	}
 
 	if len(killed) != len(wantKilled) {
 		t.Fatalf("killed: got %d entries, want %d", len(killed), len(wantKilled))
@@ -536,7 +816,7 @@ func TestComputeFinalUnsavedDist(t *testing.T) {
 	}
 	maxHits := 1
 
-	got := computeFinalUnsavedDist(jointWoundDist, maxHits, 0.6)
+	got := computeFinalUnsavedDist(jointWoundDist, maxHits, saveRolls{probFailed: 0.6})
 
 	want := []float64{0.4, 0.6} // 0 unsaved (40%) or 1 unsaved (60%)
 
@@ -600,7 +880,7 @@ func TestComputeJointWoundDist(t *testing.T) {
 	}
 	bounds := hitBounds{maxN: 1, maxL: 0, maxHits: 1}
 
-	got := computeJointWoundDist(autoWoundNormalHitDist, bounds, 0.5, 0.25)
+	got := computeJointWoundDist(autoWoundNormalHitDist, bounds, 0.5, 0.25, nil)
 
 	want := NormalDevastatingWoundMatrix{
 		{0.25, 0.25}, // normWounds=0: miss (devWounds=0) or devastating (devWounds=1)
@@ -631,7 +911,7 @@ func TestComputeAutoWoundNormalHitDist(t *testing.T) {
 	}
 	bounds := computeHitBounds(attackCountDist, hitOutcomeDist)
 
-	got := computeAutoWoundNormalHitDist(hitOutcomeDist, attackCountDist, bounds)
+	got := computeAutoWoundNormalHitDist(hitOutcomeDist, attackCountDist, bounds, nil)
 
 	want := AutoWoundNormalHitMatrix{
 		{0, 0.6}, // auto=0: normal=0 -> 0, normal=1 -> 0.6
@@ -706,7 +986,7 @@ func TestComputeHitOutcomeDist(t *testing.T) {
 		}
 
 		got := computeHitOutcomeDist(req)
-		want := CalculateSingleHitDistribution(3, RerollOnes, 1, true, 1, 6)
+		want := CalculateSingleHitDistribution(3, RerollOnes, 1, CriticalHitEffects{AutoWound: true, ExtraHits: DiceRoll{Modifier: 1}}, 6)
 
 		if len(got) != len(want) {
 			t.Fatalf("got %d outcomes, want %d", len(got), len(want))
@@ -837,6 +1117,29 @@ func TestDamageCalculatorImpl_Hydrate(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Model Groups Set Count and Clamp Saves",
+			input: CombatSimulationRequest{
+				Attacker: AttackerProfile{BS: 3},
+				Target: TargetProfile{
+					Groups: []ModelGroup{
+						{Count: 4, WoundsPerModel: 1, Save: 1},
+						{Count: 1, WoundsPerModel: 4, Save: 2, Invulnerable: intPtr(1), Character: true},
+					},
+				},
+			},
+			check: func(t *testing.T, got CombatSimulationRequest) {
+				if got.Target.Count == nil || *got.Target.Count != 5 {
+					t.Fatalf("Target.Count should be the sum of the groups (5), got %v", got.Target.Count)
+				}
+				if got.Target.Groups[0].Save != 2 {
+					t.Errorf("Group Save 1 should clamp to 2, got %d", got.Target.Groups[0].Save)
+				}
+				if *got.Target.Groups[1].Invulnerable != 2 {
+					t.Errorf("Group Invulnerable 1 should clamp to 2, got %d", *got.Target.Groups[1].Invulnerable)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -908,10 +1211,70 @@ func TestDefaultComplexityValidator(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Critical AP bonuses on both rolls",
+			req: CombatSimulationRequest{
+				// Four wound classes: every volley stream is thinned by
+				// four saving throws.
+				Attacker: AttackerProfile{Count: 20, Attacks: DiceRoll{Modifier: 3}, Damage: DiceRoll{Modifier: 1},
+					CriticalHitAPBonus: 1, CriticalWoundAPBonus: 1},
+				Target: TargetProfile{Count: intPtr(10), WoundsPerModel: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Critical AP bonuses on both rolls, few attacks",
+			req: CombatSimulationRequest{
+				Attacker: AttackerProfile{Count: 5, Attacks: DiceRoll{Modifier: 3}, Damage: DiceRoll{Modifier: 1},
+					CriticalHitAPBonus: 1, CriticalWoundAPBonus: 1},
+				Target: TargetProfile{Count: intPtr(10), WoundsPerModel: 2},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Critical AP bonuses on both rolls with Sustained Hits D3",
+			req: CombatSimulationRequest{
+				Attacker: AttackerProfile{Count: 10, Attacks: DiceRoll{Modifier: 3}, Damage: DiceRoll{Modifier: 1},
+					CriticalHitAPBonus: 1, CriticalWoundAPBonus: 1, SustainedHitsDice: DiceRoll{Count: 1, Sides: 3}},
+				Target: TargetProfile{Count: intPtr(10), WoundsPerModel: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Mortal wounds and Devastating Wounds",
+			req: CombatSimulationRequest{
+				Attacker: AttackerProfile{Count: 60, Attacks: DiceRoll{Modifier: 2}, Damage: DiceRoll{Modifier: 1},
+					SustainedHits: 1, DevastatingWounds: true,
+					MortalWoundsOn: MortalWoundsOnCriticalHit, MortalWounds: DiceRoll{Modifier: 1}},
+				Target: TargetProfile{Count: intPtr(10), WoundsPerModel: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Profiles that pass alone add up",
+			req: func() CombatSimulationRequest {
+				// Each profile scores 7·200² + 200² + 4·200·20 = 336,000.
+				profile := AttackerProfile{Count: 40, Attacks: DiceRoll{Modifier: 5}, Damage: DiceRoll{Modifier: 1}}
+				req := CombatSimulationRequest{
+					Attacker: profile,
+					Target:   TargetProfile{Count: intPtr(10), WoundsPerModel: 2},
+				}
+				for range 29 {
+					req.Weapons = append(req.Weapons, profile)
+				}
+				return req
+			}(),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			single := tt.req
+			single.Weapons = nil
+			if len(tt.req.Weapons) > 0 && DefaultComplexityValidator(&single) != nil {
+				t.Fatal("each profile should pass on its own")
+			}
 			err := DefaultComplexityValidator(&tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DefaultComplexityValidator() error = %v, wantErr %v", err, tt.wantErr)
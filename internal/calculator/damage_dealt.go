@@ -0,0 +1,222 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+// dealtStates is a joint PMF over the damage dealt so far and the target's
+// wound state: dealtStates[d][s]. Against a unit of mixed models the
+// damage of a wound depends on the model it is allocated to, so the
+// damage dealt has to be tracked alongside the wound states.
+type dealtStates [][]float64
+
+// newDealtStates returns the joint PMF of wound states before any damage
+// is dealt.
+func newDealtStates(states []float64) dealtStates {
+	return dealtStates{append([]float64(nil), states...)}
+}
+
+// states returns the PMF of the wound state.
+func (j dealtStates) states() []float64 {
+	if len(j) == 0 {
+		return nil
+	}
+	res := make([]float64, len(j[0]))
+	for _, states := range j {
+		for s, p := range states {
+			res[s] += p
+		}
+	}
+	return res
+}
+
+// damage returns the PMF of the damage dealt.
+func (j dealtStates) damage() []float64 {
+	res := make([]float64, len(j))
+	for d, states := range j {
+		for _, p := range states {
+			res[d] += p
+		}
+	}
+	return res
+}
+
+// grow extends j to at least n damage values over width states.
+func (j dealtStates) grow(n, width int) dealtStates {
+	for len(j) < n {
+		j = append(j, make([]float64, width))
+	}
+	return j
+}
+
+// addTo adds weight times j to dest, and returns dest.
+func (j dealtStates) addTo(dest dealtStates, weight float64) dealtStates {
+	for d, states := range j {
+		dest = dest.grow(d+1, len(states))
+		for s, p := range states {
+			if p < negligibleProbability {
+				continue
+			}
+			dest[d][s] += p * weight
+		}
+	}
+	return dest
+}
+
+// dealWound adds to next the outcome of allocating one more wound, whose
+// damage the model allocated to rolls from dmgDists, and returns next.
+// Once the unit is destroyed, its wounds still count the damage of the
+// model allocated to last, as a target of identical models does.
+func (j dealtStates) dealWound(next dealtStates, dmgDists []map[int]float64, layout *targetLayout, spills bool) dealtStates {
+	last := layout.lastGroup()
+	for d, states := range j {
+		for s, p := range states {
+			if p <= negligibleProbability {
+				continue
+			}
+			group := last
+			if s > 0 {
+				group = layout.groupAt(s)
+			}
+			for dmg, pDmg := range dmgDists[group] {
+				after := 0
+				if s > 0 {
+					after = layout.afterDamage(s, dmg, spills)
+				}
+				next = next.grow(d+dmg+1, len(states))
+				next[d+dmg][after] += p * pDmg
+			}
+		}
+	}
+	return next
+}
+
+// allocateDealt resolves the volley against a joint PMF of the damage
+// dealt so far and the wound state, like allocate.
+func (v weaponVolley) allocateDealt(initial dealtStates) dealtStates {
+	keys, mortalWeights := allocationKeys(unsavedStreams(v.streamDist, failedSaves().unsavedCounts))
+	prefix := &dealtPrefix{
+		states: []dealtStates{initial},
+		dists:  streamDamage(v.damage, func(d groupDamage) rolledDamage { return d.saveable[0] }).dist,
+		layout: v.layout,
+	}
+	var result dealtStates
+	for _, k := range keys {
+		result = resolveDealt(prefix, k, mortalWeights[k], v.damage, v.layout, result)
+	}
+	return result
+}
+
+// dealtPrefix caches the joint PMFs after each number of unsaved wounds
+// of the first class, which every allocation key starts with: without it
+// a volley of n wounds would deal O(n²) of them.
+type dealtPrefix struct {
+	states []dealtStates
+	dists  []map[int]float64
+	layout *targetLayout
+}
+
+// after returns the joint PMF after n wounds of the first class.
+func (p *dealtPrefix) after(n int) dealtStates {
+	for len(p.states) <= n {
+		last := p.states[len(p.states)-1]
+		p.states = append(p.states, last.dealWound(nil, p.dists, p.layout, false))
+	}
+	return p.states[n]
+}
+
+// resolveDealt is resolveDamageToSlice over a joint PMF of the damage
+// dealt and the wound state: it allocates the wounds of k, then the
+// mortal wounds, adding the weighted outcomes to dest. The wounds of the
+// first class are read off prefix.
+func resolveDealt(prefix *dealtPrefix, k allocationKey, mortalWeights []float64, dists []groupDamage, layout *targetLayout, dest dealtStates) dealtStates {
+	var j dealtStates
+	if dists[0].hasSingleReroll() {
+		j = dealWithSingleReroll(prefix.after(0), k, dists, layout)
+	} else {
+		j = prefix.after(k.unsaved[0])
+		for class, n := range k.unsaved {
+			if class == 0 {
+				continue
+			}
+			classDists := streamDamage(dists, func(d groupDamage) rolledDamage { return d.saveable[class] })
+			for range n {
+				j = j.dealWound(nil, classDists.dist, layout, false)
+			}
+		}
+		devDists := streamDamage(dists, func(d groupDamage) rolledDamage { return d.devastating })
+		for range k.devastating {
+			j = j.dealWound(nil, devDists.dist, layout, false)
+		}
+	}
+
+	mortalDists := make([]map[int]float64, len(dists))
+	for i, d := range dists {
+		mortalDists[i] = d.mortal
+	}
+	for m, weight := range mortalWeights {
+		if m > 0 {
+			j = j.dealWound(nil, mortalDists, layout, true)
+		}
+		if weight < negligibleProbability {
+			continue
+		}
+		dest = j.addTo(dest, weight)
+	}
+	return dest
+}
+
+// dealWithSingleReroll is resolveWithSingleReroll over a joint PMF of the
+// damage dealt and the wound state. Unlike there, a destroyed unit keeps
+// rolling damage, so the reroll may still be spent on it.
+func dealWithSingleReroll(j dealtStates, k allocationKey, dists []groupDamage, layout *targetLayout) dealtStates {
+	avail, spent := j, dealtStates(nil)
+	resolve := func(n int, stream streamDamageDists) {
+		for range n {
+			nextSpent := avail.dealWound(nil, stream.spent, layout, false)
+			nextSpent = spent.dealWound(nextSpent, stream.dist, layout, false)
+			avail = avail.dealWound(nil, stream.kept, layout, false)
+			spent = nextSpent
+		}
+	}
+	for class, n := range k.unsaved {
+		resolve(n, streamDamage(dists, func(d groupDamage) rolledDamage { return d.saveable[class] }))
+	}
+	resolve(k.devastating, streamDamage(dists, func(d groupDamage) rolledDamage { return d.devastating }))
+	return spent.addTo(avail.addTo(nil, 1), 1)
+}
+
+// streamDamageDists holds one stream's rolledDamage PMFs per group.
+type streamDamageDists struct {
+	dist, kept, spent []map[int]float64
+}
+
+// streamDamage collects the rolledDamage of one stream from every group.
+func streamDamage(dists []groupDamage, stream func(groupDamage) rolledDamage) streamDamageDists {
+	res := streamDamageDists{
+		dist:  make([]map[int]float64, len(dists)),
+		kept:  make([]map[int]float64, len(dists)),
+		spent: make([]map[int]float64, len(dists)),
+	}
+	for i, d := range dists {
+		r := stream(d)
+		res.dist[i], res.kept[i], res.spent[i] = r.dist, r.kept, r.spent
+	}
+	return res
+}
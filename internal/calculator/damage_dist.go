@@ -28,12 +28,16 @@ import (
 //
 // Arguments:
 // damageString: e.g., "d6", "2d6", "d3+1", "3".
+// opts: How the Damage roll is made (rerolls, best-of, minimum).
+// mods: Modifiers to the Damage characteristic (Melta, damage reduction, halving).
 // feelNoPain: Optional pointer to FNP value (e.g., 5 for 5+). nil if none.
 //
 // Returns:
 // map[int]float64: Mapping of DamageAmount -> Probability (0.0 to 1.0).
-func _calculateDamageDistribution(damage DiceRoll, feelNoPain *int) map[int]float64 {
-	baseDist := generateDiceDistribution(damage)
+func _calculateDamageDistribution(damage DiceRoll, opts DiceOptions, mods damageModifiers, feelNoPain *int) map[int]float64 {
+	// Modifiers change the Damage characteristic itself, so they are
+	// resolved before FNP: every point of damage gets its own FNP roll.
+	baseDist := mods.apply(generateDiceDistribution(damage, opts))
 
 	if feelNoPain == nil {
 		return baseDist
@@ -42,27 +46,380 @@ func _calculateDamageDistribution(damage DiceRoll, feelNoPain *int) map[int]floa
 	return applyFeelNoPain(baseDist, *feelNoPain)
 }
 
-// generateDiceDistribution computes the exact PMF of a dice roll via direct
-// convolution (no string parsing involved).
-func generateDiceDistribution(d DiceRoll) map[int]float64 {
-	// Base case: If there are no dice to roll (e.g., flat damage "3"),
-	// start with 100% probability at 0, then add the modifier.
-	if d.Count <= 0 || d.Sides <= 0 {
-		return map[int]float64{applyDamageFloor(d.Modifier): 1.0}
+// damageModifiers are the modifiers to an attack's Damage characteristic.
+type damageModifiers struct {
+	Bonus     int  // e.g. the active [MELTA X] value
+	Reduction int  // "-1 Damage" style abilities
+	Halve     bool // "halve the Damage characteristic", rounding up
+}
+
+// apply modifies every outcome of a damage PMF in rules order: halving
+// first, then additions and subtractions, and never below 1.
+func (m damageModifiers) apply(dist map[int]float64) map[int]float64 {
+	if m == (damageModifiers{}) {
+		return dist
+	}
+	res := make(map[int]float64, len(dist))
+	for val, p := range dist {
+		if m.Halve {
+			val = (val + 1) / 2
+		}
+		res[applyDamageFloor(val+m.Bonus-m.Reduction)] += p
 	}
+	return res
+}
+
+// damageModifiersFor returns the Damage characteristic modifiers for
+// wounds allocated through the given stream. Mortal wounds have no Damage
+// characteristic and are not expected here.
+func damageModifiersFor(req CombatSimulationRequest, stream woundStream) damageModifiers {
+	mods := damageModifiers{Bonus: halfRangeBonus(req.Attacker.MeltaX, req.Settings.HalfRange)}
+	if stream == criticalWoundStream || stream == devastatingStream {
+		mods.Bonus += req.Attacker.CriticalWoundDamageBonus
+	}
+	if stream == devastatingStream && req.Target.DamageReductionExcludesDevastating {
+		return mods
+	}
+	mods.Reduction = req.Target.DamageReduction
+	mods.Halve = req.Target.HalveDamage
+	return mods
+}
 
-	currentDist := rollDiceDistribution(d.Count, d.Sides)
+// woundStream identifies how a wound's damage reaches the target, which
+// decides the Feel No Pain rolls that can ignore it.
+type woundStream int
+
+const (
+	normalStream woundStream = iota
+	// criticalWoundStream is a Critical Wound that rolls a saving throw.
+	criticalWoundStream
+	devastatingStream
+	mortalStream
+)
+
+// feelNoPainFor returns the best Feel No Pain roll the target can make
+// against damage from the given stream, or nil if none applies.
+func feelNoPainFor(target TargetProfile, stream woundStream) *int {
+	var best *int
+	consider := func(fnp *int) {
+		if fnp != nil && (best == nil || *fnp < *best) {
+			best = fnp
+		}
+	}
+
+	switch stream {
+	case normalStream, criticalWoundStream:
+		consider(target.FeelNoPain)
+	case devastatingStream:
+		if !target.FeelNoPainExcludesDevastating {
+			consider(target.FeelNoPain)
+		}
+	case mortalStream:
+		consider(target.FeelNoPain)
+		consider(target.MortalFeelNoPain)
+	}
+	return best
+}
+
+// streamDamageDist returns the per-wound damage PMF of a stream after
+// Damage modifiers and Feel No Pain. A mortal wound is a single point of
+// damage.
+func streamDamageDist(req CombatSimulationRequest, stream woundStream) map[int]float64 {
+	if stream == mortalStream {
+		return _calculateDamageDistribution(DiceRoll{Modifier: 1}, DiceOptions{}, damageModifiers{}, feelNoPainFor(req.Target, stream))
+	}
+	return _calculateDamageDistribution(req.Attacker.Damage, req.Attacker.DamageOptions,
+		damageModifiersFor(req, stream), feelNoPainFor(req.Target, stream))
+}
+
+// groupDamage holds the per-wound damage PMFs of each stream against the
+// models of one group.
+type groupDamage struct {
+	// saveable holds the damage of the wounds of each woundClass.
+	saveable    [numWoundClasses]rolledDamage
+	devastating rolledDamage
+	mortal      map[int]float64
+}
+
+// rolledDamage is the per-wound damage PMF of a stream whose Damage is
+// rolled. With a single reroll of a Damage roll (or, against Groups, of
+// a saving throw), dist is split between the wounds that keep it (kept)
+// and the wounds that spend it, followed by the rerolled roll (spent).
+// Both are nil if there is no such reroll.
+type rolledDamage struct {
+	dist, kept, spent map[int]float64
+}
+
+// hasSingleReroll reports whether the damage PMFs carry a single reroll.
+func (g groupDamage) hasSingleReroll() bool {
+	return g.saveable[0].spent != nil
+}
+
+// classStream returns the stream whose damage the wounds of a class deal.
+func classStream(class woundClass) woundStream {
+	if class&criticalWoundClass != 0 {
+		return criticalWoundStream
+	}
+	return normalStream
+}
+
+// keepingSingleReroll returns g with every stream that cannot spend the
+// single reroll split as one that always keeps it.
+func (g groupDamage) keepingSingleReroll() groupDamage {
+	keep := func(d rolledDamage) rolledDamage {
+		if d.spent == nil {
+			d.kept, d.spent = d.dist, map[int]float64{}
+		}
+		return d
+	}
+	for class := range g.saveable {
+		g.saveable[class] = keep(g.saveable[class])
+	}
+	g.devastating = keep(g.devastating)
+	return g
+}
+
+// streamDamageReroll splits the per-wound damage PMF of a stream for a
+// single Damage reroll: it is spent on the first roll whose dice are
+// below their average, and the new roll is kept. A best-of roll is
+// rerolled as a whole. kept and spent are nil if the Damage
+// characteristic has no dice, or if the profile rerolls its Damage rolls
+// with a policy of its own.
+func streamDamageReroll(req CombatSimulationRequest, stream woundStream) (kept, spent map[int]float64) {
+	damage, opts := req.Attacker.Damage, req.Attacker.DamageOptions
+	single := req.Settings.SingleReroll.Damage || opts.Reroll == DiceRerollSingle
+	if !single || damage.Count <= 0 || damage.Sides <= 0 ||
+		opts.Reroll == DiceRerollBelowAverage || opts.Reroll == DiceRerollBelow {
+		return nil, nil
+	}
+
+	roll := rerolledDiceDistribution(damage, DiceOptions{})
+	if opts.BestOf > 1 {
+		roll = bestOfDist(roll, opts.BestOf)
+	}
+	probLow := 0.0
+	high := make(map[int]float64)
+	for raw, p := range roll {
+		if belowDiceAverage(damage, raw-damage.Modifier) {
+			probLow += p
+			continue
+		}
+		high[diceResult(raw, opts.Minimum)] += p
+	}
+	if probLow == 0 {
+		return nil, nil
+	}
+
+	kept = damageModifiersFor(req, stream).apply(high)
+	if fnp := feelNoPainFor(req.Target, stream); fnp != nil {
+		kept = applyFeelNoPain(kept, *fnp)
+	}
+	spent = make(map[int]float64)
+	for dVal, p := range streamDamageDist(req, stream) {
+		spent[dVal] = probLow * p
+	}
+	return kept, spent
+}
+
+// streamRolledDamage returns the damage PMF of a stream, with its split
+// for a single Damage reroll.
+func streamRolledDamage(req CombatSimulationRequest, stream woundStream) rolledDamage {
+	d := rolledDamage{dist: streamDamageDist(req, stream)}
+	d.kept, d.spent = streamDamageReroll(req, stream)
+	return d
+}
+
+// groupDamageDists returns the per-wound damage PMFs against each group of
+// layout. In a unit built from Groups the saving throw depends on the
+// model being allocated to, so it is folded into each group's damage PMF
+// of every woundClass (a saved wound deals 0) instead of being rolled up
+// front, and so is a single save reroll.
+func groupDamageDists(req CombatSimulationRequest, layout *targetLayout) []groupDamage {
+	if len(req.Target.Groups) == 0 {
+		dist := groupDamage{
+			devastating: streamRolledDamage(req, devastatingStream),
+			mortal:      streamDamageDist(req, mortalStream),
+		}
+		// The classes only differ in their saving throw and their
+		// Critical Wound damage.
+		normal, critical := streamRolledDamage(req, normalStream), streamRolledDamage(req, criticalWoundStream)
+		for class := range dist.saveable {
+			dist.saveable[class] = normal
+			if classStream(woundClass(class)) == criticalWoundStream {
+				dist.saveable[class] = critical
+			}
+		}
+		return []groupDamage{dist}
+	}
+
+	dists := make([]groupDamage, len(layout.groups))
+	for i, g := range layout.groups {
+		groupReq := req
+		groupReq.Target.FeelNoPain = g.FeelNoPain
+
+		dists[i] = groupDamage{
+			devastating: streamRolledDamage(groupReq, devastatingStream),
+			mortal:      streamDamageDist(groupReq, mortalStream),
+		}
+		for class := range dists[i].saveable {
+			saves := saveRolls{probFailed: CalculateFailedSaveProbability(
+				classAP(req.Attacker, woundClass(class)),
+				g.Save,
+				g.Invulnerable,
+				req.Settings.SaveModifier,
+				req.Target.HasCover,
+				req.Settings.SaveReroll,
+			)}
+			// As against a single group, a single save reroll is not
+			// spent on the wounds of the critical classes.
+			if class == 0 && req.Settings.SingleReroll.Save {
+				saves.singleReroll = true
+				saves.eligible, saves.freshFailed = singleSaveReroll(
+					req.Attacker.AP,
+					g.Save,
+					g.Invulnerable,
+					req.Settings.SaveModifier,
+					req.Target.HasCover,
+					req.Settings.SaveReroll,
+				)
+			}
+			dists[i].saveable[class] = savedStreamDamage(groupReq, classStream(woundClass(class)), saves)
+		}
+		if req.Settings.SingleReroll.Save {
+			dists[i] = dists[i].keepingSingleReroll()
+		}
+	}
+	return dists
+}
+
+// savedStreamDamage returns the per-wound damage PMF of a wound of the
+// given stream that rolls the given saving throw, and its split for a
+// single Damage reroll (see streamDamageReroll) or a single save reroll.
+// The two cannot be combined.
+func savedStreamDamage(req CombatSimulationRequest, stream woundStream, saves saveRolls) rolledDamage {
+	dmg := streamDamageDist(req, stream)
+	probFailed := saves.probFailed
+	d := rolledDamage{dist: foldSave(dmg, 1.0-probFailed, probFailed)}
+	if saves.singleReroll {
+		// A save that fails with no other reroll to use spends it, and
+		// the rerolled save decides the wound.
+		d.kept = foldSave(dmg, 1.0-probFailed, probFailed-saves.eligible)
+		d.spent = foldSave(dmg, saves.eligible*(1.0-saves.freshFailed), saves.eligible*saves.freshFailed)
+	} else if k, s := streamDamageReroll(req, stream); s != nil {
+		// A saved wound rolls no damage, so it keeps the reroll.
+		d.kept = foldSave(k, 1.0-probFailed, probFailed)
+		d.spent = foldSave(s, 0, probFailed)
+	}
+	return d
+}
+
+// foldSave returns the damage PMF of a wound that deals 0 with weight
+// probSaved and otherwise deals dist with weight probFailed.
+func foldSave(dist map[int]float64, probSaved, probFailed float64) map[int]float64 {
+	folded := make(map[int]float64)
+	if probSaved > 0 {
+		folded[0] = probSaved
+	}
+	for dVal, p := range dist {
+		folded[dVal] += probFailed * p
+	}
+	return folded
+}
+
+// generateDiceDistribution computes the exact PMF of a dice roll via direct
+// convolution (no string parsing involved). The options are resolved in
+// order: each roll is rerolled by its policy, the best of BestOf rolls is
+// kept and the result is raised to Minimum.
+func generateDiceDistribution(d DiceRoll, opts DiceOptions) map[int]float64 {
+	roll := rerolledDiceDistribution(d, opts)
+	if opts.BestOf > 1 {
+		roll = bestOfDist(roll, opts.BestOf)
+	}
 
 	// In 40k, damage/attacks generally cannot be modified below 1.
 	finalDist := make(map[int]float64)
-	for val, p := range currentDist {
-		result := applyDamageFloor(val + d.Modifier)
-		finalDist[result] += p
+	for val, p := range roll {
+		finalDist[diceResult(val, opts.Minimum)] += p
 	}
 
 	return finalDist
 }
 
+// rerolledDiceDistribution returns the PMF of the raw total (dice plus
+// Modifier, before any floor) of one roll after the reroll policy of opts.
+// A single reroll is not a policy: it is resolved by the caller.
+func rerolledDiceDistribution(d DiceRoll, opts DiceOptions) map[int]float64 {
+	// Base case: If there are no dice to roll (e.g., flat damage "3"),
+	// the total is the modifier and there is nothing to reroll.
+	if d.Count <= 0 || d.Sides <= 0 {
+		return map[int]float64{d.Modifier: 1.0}
+	}
+
+	sums := rollDiceDistribution(d.Count, d.Sides)
+	dist := make(map[int]float64, len(sums))
+	probRerolled := 0.0
+	for sum, p := range sums {
+		if opts.rerolls(d, sum) {
+			probRerolled += p
+			continue
+		}
+		dist[sum+d.Modifier] += p
+	}
+	if probRerolled > 0 {
+		for sum, p := range sums {
+			dist[sum+d.Modifier] += probRerolled * p
+		}
+	}
+	return dist
+}
+
+// rerolls reports whether the reroll policy of o rerolls a roll of d
+// whose dice total sum.
+func (o DiceOptions) rerolls(d DiceRoll, sum int) bool {
+	switch o.Reroll {
+	case DiceRerollBelowAverage:
+		return belowDiceAverage(d, sum)
+	case DiceRerollBelow:
+		return diceResult(sum+d.Modifier, o.Minimum) < o.RerollBelow
+	}
+	return false
+}
+
+// belowDiceAverage reports whether the dice of d totalling sum are below
+// their average of Count*(Sides+1)/2.
+func belowDiceAverage(d DiceRoll, sum int) bool {
+	return 2*sum < d.Count*(d.Sides+1)
+}
+
+// diceResult returns the result of a roll with the given raw total: never
+// below 1, nor below minimum.
+func diceResult(raw, minimum int) int {
+	return max(applyDamageFloor(raw), minimum)
+}
+
+// bestOfDist returns the PMF of the highest of n independent rolls of
+// dist: P(max <= v) = P(roll <= v)^n.
+func bestOfDist(dist map[int]float64, n int) map[int]float64 {
+	lo, hi := math.MaxInt, math.MinInt
+	for v := range dist {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	res := make(map[int]float64, len(dist))
+	cdf, prev := 0.0, 0.0
+	for v := lo; v <= hi; v++ {
+		p, ok := dist[v]
+		if !ok {
+			continue
+		}
+		cdf += p
+		cur := math.Pow(cdf, float64(n))
+		res[v] = cur - prev
+		prev = cur
+	}
+	return res
+}
+
 // applyFeelNoPain applies the Binomial Distribution logic.
 func applyFeelNoPain(baseDist map[int]float64, fnpVal int) map[int]float64 {
 	fnpDist := make(map[int]float64)
@@ -32,6 +32,10 @@ func TestCalculateDamageDistribution(t *testing.T) {
 	tests := []struct {
 		name          string
 		damage        DiceRoll
+		opts          DiceOptions
+		melta         int
+		reduction     int
+		halve         bool
 		fnp           *int
 		expectedCheck map[int]float64
 	}{
@@ -103,12 +107,148 @@ func TestCalculateDamageDistribution(t *testing.T) {
 				1: 5.0 / 6.0,
 			},
 		},
+		{
+			name:   "d6 with Melta 2",
+			damage: DiceRoll{Count: 1, Sides: 6, Modifier: 0},
+			melta:  2,
+			expectedCheck: map[int]float64{
+				3: 1.0 / 6.0,
+				4: 1.0 / 6.0,
+				5: 1.0 / 6.0,
+				6: 1.0 / 6.0,
+				7: 1.0 / 6.0,
+				8: 1.0 / 6.0,
+			},
+		},
+		{
+			// Melta is added before FNP: a flat 1 + Melta 1 is 2 points, each rolled for.
+			name:   "Static 1 with Melta 1 and FNP 5+",
+			damage: DiceRoll{Count: 0, Sides: 0, Modifier: 1},
+			melta:  1,
+			fnp:    intPtr(5),
+			expectedCheck: map[int]float64{
+				0: 1.0 / 9.0,
+				1: 4.0 / 9.0,
+				2: 4.0 / 9.0,
+			},
+		},
+		{
+			name:      "d6 with -1 Damage never drops below 1",
+			damage:    DiceRoll{Count: 1, Sides: 6, Modifier: 0},
+			reduction: 1,
+			expectedCheck: map[int]float64{
+				1: 2.0 / 6.0,
+				2: 1.0 / 6.0,
+				3: 1.0 / 6.0,
+				4: 1.0 / 6.0,
+				5: 1.0 / 6.0,
+			},
+		},
+		{
+			name:   "d6 halved rounds up",
+			damage: DiceRoll{Count: 1, Sides: 6, Modifier: 0},
+			halve:  true,
+			expectedCheck: map[int]float64{
+				1: 2.0 / 6.0,
+				2: 2.0 / 6.0,
+				3: 2.0 / 6.0,
+			},
+		},
+		{
+			// Halving comes first: ceil(6/2) + 2 - 1 = 4, not ceil((6+2-1)/2).
+			name:      "Static 6 halved, then Melta 2 and -1 Damage",
+			damage:    DiceRoll{Count: 0, Sides: 0, Modifier: 6},
+			melta:     2,
+			reduction: 1,
+			halve:     true,
+			expectedCheck: map[int]float64{
+				4: 1.0,
+			},
+		},
+		{
+			// The reduction happens before FNP: 3 - 1 = 2 points, each rolled for.
+			name:      "Static 3 with -1 Damage and FNP 5+",
+			damage:    DiceRoll{Count: 0, Sides: 0, Modifier: 3},
+			reduction: 1,
+			fnp:       intPtr(5),
+			expectedCheck: map[int]float64{
+				0: 1.0 / 9.0,
+				1: 4.0 / 9.0,
+				2: 4.0 / 9.0,
+			},
+		},
+		{
+			// 1-3 are below the average of 3.5 and are rerolled.
+			name:   "d6 rerolling below-average rolls",
+			damage: DiceRoll{Count: 1, Sides: 6},
+			opts:   DiceOptions{Reroll: DiceRerollBelowAverage},
+			expectedCheck: map[int]float64{
+				1: 1.0 / 12.0, 2: 1.0 / 12.0, 3: 1.0 / 12.0,
+				4: 1.0 / 4.0, 5: 1.0 / 4.0, 6: 1.0 / 4.0,
+			},
+		},
+		{
+			name:   "d6 rerolling results below 3",
+			damage: DiceRoll{Count: 1, Sides: 6},
+			opts:   DiceOptions{Reroll: DiceRerollBelow, RerollBelow: 3},
+			expectedCheck: map[int]float64{
+				1: 1.0 / 18.0, 2: 1.0 / 18.0,
+				3: 2.0 / 9.0, 4: 2.0 / 9.0, 5: 2.0 / 9.0, 6: 2.0 / 9.0,
+			},
+		},
+		{
+			name:   "d6 minimum 3",
+			damage: DiceRoll{Count: 1, Sides: 6},
+			opts:   DiceOptions{Minimum: 3},
+			expectedCheck: map[int]float64{
+				3: 1.0 / 2.0, 4: 1.0 / 6.0, 5: 1.0 / 6.0, 6: 1.0 / 6.0,
+			},
+		},
+		{
+			// P(max = v) = (2v - 1) / 36.
+			name:   "Best of two d6",
+			damage: DiceRoll{Count: 1, Sides: 6},
+			opts:   DiceOptions{BestOf: 2},
+			expectedCheck: map[int]float64{
+				1: 1.0 / 36.0, 2: 3.0 / 36.0, 3: 5.0 / 36.0,
+				4: 7.0 / 36.0, 5: 9.0 / 36.0, 6: 11.0 / 36.0,
+			},
+		},
+		{
+			// The minimum applies to the kept roll: 1/9 + 3/9 become 2.
+			name:   "Best of two d3, minimum 2",
+			damage: DiceRoll{Count: 1, Sides: 3},
+			opts:   DiceOptions{BestOf: 2, Minimum: 2},
+			expectedCheck: map[int]float64{
+				2: 4.0 / 9.0, 3: 5.0 / 9.0,
+			},
+		},
+		{
+			// Results of 1-3 count as 3, which is below 4 and rerolled.
+			name:   "d6 minimum 3, rerolling results below 4",
+			damage: DiceRoll{Count: 1, Sides: 6},
+			opts:   DiceOptions{Reroll: DiceRerollBelow, RerollBelow: 4, Minimum: 3},
+			expectedCheck: map[int]float64{
+				3: 1.0 / 4.0, 4: 1.0 / 4.0, 5: 1.0 / 4.0, 6: 1.0 / 4.0,
+			},
+		},
+		{
+			// The minimum is part of the roll; -1 Damage still applies.
+			name:      "d3 minimum 2 with -1 Damage",
+			damage:    DiceRoll{Count: 1, Sides: 3},
+			opts:      DiceOptions{Minimum: 2},
+			reduction: 1,
+			expectedCheck: map[int]float64{
+				1: 2.0 / 3.0, 2: 1.0 / 3.0,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Calling the internal distribution logic
-			gotDist := _calculateDamageDistribution(tt.damage, tt.fnp)
+			mods := damageModifiers{Bonus: tt.melta, Reduction: tt.reduction, Halve: tt.halve}
+			gotDist := _calculateDamageDistribution(tt.damage, tt.opts, mods, tt.fnp)
 
 			for dmgVal, expectedProb := range tt.expectedCheck {
 				gotProb, exists := gotDist[dmgVal]
@@ -134,3 +274,69 @@ func TestCalculateDamageDistribution(t *testing.T) {
 		})
 	}
 }
+
+func TestFeelNoPainFor(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name   string
+		target TargetProfile
+		stream woundStream
+		want   *int
+	}{
+		{
+			name:   "No FNP",
+			target: TargetProfile{},
+			stream: normalStream,
+			want:   nil,
+		},
+		{
+			name:   "General FNP applies to normal damage",
+			target: TargetProfile{FeelNoPain: intPtr(5), MortalFeelNoPain: intPtr(3)},
+			stream: normalStream,
+			want:   intPtr(5),
+		},
+		{
+			name:   "General FNP applies to devastating damage",
+			target: TargetProfile{FeelNoPain: intPtr(5)},
+			stream: devastatingStream,
+			want:   intPtr(5),
+		},
+		{
+			name:   "General FNP excluded from devastating damage",
+			target: TargetProfile{FeelNoPain: intPtr(5), FeelNoPainExcludesDevastating: true},
+			stream: devastatingStream,
+			want:   nil,
+		},
+		{
+			name:   "Mortal-only FNP does not apply to devastating damage",
+			target: TargetProfile{MortalFeelNoPain: intPtr(4)},
+			stream: devastatingStream,
+			want:   nil,
+		},
+		{
+			name:   "Mortal wounds use the better of both",
+			target: TargetProfile{FeelNoPain: intPtr(6), MortalFeelNoPain: intPtr(4)},
+			stream: mortalStream,
+			want:   intPtr(4),
+		},
+		{
+			name:   "General FNP still applies to mortal wounds when excluded from devastating",
+			target: TargetProfile{FeelNoPain: intPtr(5), FeelNoPainExcludesDevastating: true},
+			stream: mortalStream,
+			want:   intPtr(5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := feelNoPainFor(tt.target, tt.stream)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if got != nil && *got != *tt.want {
+				t.Errorf("got %d+, want %d+", *got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+// hazardousMortalWounds is the number of mortal wounds a CHARACTER,
+// MONSTER or VEHICLE model suffers for a failed Hazardous test.
+const hazardousMortalWounds = 3
+
+// hazardousDestroyedDist returns the PMF of the attacking models a
+// [HAZARDOUS] profile destroys. After the profile has fired, each of its
+// Count models takes a Hazardous test, failed on a 1:
+//
+//   - a model without the CHARACTER, MONSTER or VEHICLE keywords is
+//     destroyed for each failed test;
+//   - otherwise a model suffers 3 mortal wounds for each failed test.
+//     They are allocated to the same model until it is destroyed, and
+//     wounds left over are lost, so a model of WoundsPerModel wounds is
+//     destroyed by every ⌈WoundsPerModel/3⌉ failed tests.
+//
+// A profile without Hazardous never destroys any.
+func hazardousDestroyedDist(a AttackerProfile) map[int]float64 {
+	if !a.Hazardous || a.Count <= 0 {
+		return map[int]float64{0: 1.0}
+	}
+
+	testsPerModel := 1
+	if hasKeyword(a.Keywords, "CHARACTER") || hasKeyword(a.Keywords, "MONSTER") || hasKeyword(a.Keywords, "VEHICLE") {
+		wounds := max(a.WoundsPerModel, 1)
+		testsPerModel = (wounds + hazardousMortalWounds - 1) / hazardousMortalWounds
+	}
+
+	dist := make(map[int]float64)
+	for failed, p := range getBinomialVector(a.Count, 1.0/6.0) {
+		dist[failed/testsPerModel] += p
+	}
+	return pruneDist(dist)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "testing"
+
+func TestHazardousDestroyedDist(t *testing.T) {
+	// P(k of 2 models fail) for a 1-in-6 test.
+	none, one, both := 25.0/36.0, 10.0/36.0, 1.0/36.0
+
+	tests := []struct {
+		name     string
+		attacker AttackerProfile
+		want     map[int]float64
+	}{
+		{
+			name:     "Not Hazardous",
+			attacker: AttackerProfile{Count: 2},
+			want:     map[int]float64{0: 1.0},
+		},
+		{
+			name:     "Each failed test destroys a model",
+			attacker: AttackerProfile{Count: 2, Hazardous: true, WoundsPerModel: 2},
+			want:     map[int]float64{0: none, 1: one, 2: both},
+		},
+		{
+			name:     "3 mortal wounds destroy a 3-wound Character",
+			attacker: AttackerProfile{Count: 2, Hazardous: true, WoundsPerModel: 3, Keywords: []string{"Character"}},
+			want:     map[int]float64{0: none, 1: one, 2: both},
+		},
+		{
+			name:     "A 4-wound Vehicle takes two failed tests",
+			attacker: AttackerProfile{Count: 2, Hazardous: true, WoundsPerModel: 4, Keywords: []string{"VEHICLE"}},
+			want:     map[int]float64{0: none + one, 1: both},
+		},
+		{
+			name:     "A 12-wound Monster survives both tests",
+			attacker: AttackerProfile{Count: 2, Hazardous: true, WoundsPerModel: 12, Keywords: []string{"MONSTER"}},
+			want:     map[int]float64{0: 1.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifyDist(t, "SelfDestroyedDist", hazardousDestroyedDist(tt.attacker), tt.want)
+		})
+	}
+}
+
+func TestCalculateDamageCore_Hazardous(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	req := generateBaseRequest()
+	req.Attacker.Count = 1
+	req.Attacker.Hazardous = true
+	second := req.Attacker
+	req.Weapons = []AttackerProfile{second}
+
+	res, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Two profiles of one model each: the tests are independent.
+	verifyDist(t, "SelfDestroyedDist", res.SelfDestroyedDist, map[int]float64{
+		0: 25.0 / 36.0, 1: 10.0 / 36.0, 2: 1.0 / 36.0,
+	})
+	if len(res.Weapons) != 2 {
+		t.Fatalf("expected 2 weapon results, got %d", len(res.Weapons))
+	}
+	verifyDist(t, "Weapons[0].SelfDestroyedDist", res.Weapons[0].SelfDestroyedDist, map[int]float64{
+		0: 5.0 / 6.0, 1: 1.0 / 6.0,
+	})
+}
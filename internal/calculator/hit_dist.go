@@ -26,6 +26,63 @@ package calculator
 type HitOutcome struct {
 	NormalHits int
 	LethalHits int
+	// CriticalHits are hits from a Critical Hit that still roll to wound
+	// but carry the Strength or AP of the profile's CriticalHitEffects.
+	// They only occur when those effects must be told apart (see
+	// CriticalHitEffects.tracksCriticalHits); the dense hit matrices count
+	// them as normal hits.
+	CriticalHits int
+}
+
+// CriticalHitEffects are what a Critical Hit does besides hitting. They
+// are built from the profile's abilities by criticalHitEffects.
+type CriticalHitEffects struct {
+	// ExtraHits are further normal hits scored, e.g. [SUSTAINED HITS D3].
+	ExtraHits DiceRoll
+	// AutoWound: the hit wounds automatically ([LETHAL HITS]).
+	AutoWound bool
+	// MortalWounds, if set, are inflicted on the target in addition to
+	// the hit.
+	MortalWounds *DiceRoll
+	// Strength, if positive, is the Strength the hit itself rolls to
+	// wound with. Extra hits use the profile's Strength.
+	Strength int
+	// APBonus improves the AP of the hit itself, whether it wounds
+	// automatically or not.
+	APBonus int
+}
+
+// criticalHitEffects gathers the Critical Hit abilities of a profile.
+func criticalHitEffects(a AttackerProfile) CriticalHitEffects {
+	extra := a.SustainedHitsDice
+	extra.Modifier += a.SustainedHits
+	effects := CriticalHitEffects{
+		ExtraHits: extra,
+		AutoWound: a.LethalHits,
+		Strength:  a.CriticalHitStrength,
+		APBonus:   a.CriticalHitAPBonus,
+	}
+	if a.MortalWoundsOn == MortalWoundsOnCriticalHit {
+		mortals := a.MortalWounds
+		effects.MortalWounds = &mortals
+	}
+	return effects
+}
+
+// tracksCriticalHits reports whether the hit scored by a Critical Hit
+// must be kept apart from normal hits, because it is wounded or saved
+// differently.
+func (e CriticalHitEffects) tracksCriticalHits() bool {
+	return e.Strength > 0 || e.APBonus > 0
+}
+
+// extraHitsDist returns the PMF of the extra hits of a Critical Hit. A
+// fixed value may be 0, unlike a characteristic.
+func (e CriticalHitEffects) extraHitsDist() map[int]float64 {
+	if e.ExtraHits.Count <= 0 || e.ExtraHits.Sides <= 0 {
+		return map[int]float64{max(e.ExtraHits.Modifier, 0): 1.0}
+	}
+	return generateDiceDistribution(e.ExtraHits, DiceOptions{})
 }
 
 // CalculateSingleHitDistribution returns the PMF for a single attack die.
@@ -33,8 +90,7 @@ func CalculateSingleHitDistribution(
 	bs int,
 	rerollType RerollType,
 	hitModifier int,
-	lethalHits bool,
-	sustainedHits int,
+	effects CriticalHitEffects,
 	criticalThreshold int,
 ) map[HitOutcome]float64 {
 
@@ -43,7 +99,7 @@ func CalculateSingleHitDistribution(
 	// 1. Determine Probability of each Face (1-6) considering Rerolls
 	// We calculate the weight of each face out of 36 (for clean math) or float.
 	// Basic P(x) = 1/6.
-	faceProbs := resolveRerolls(bs, hitModifier, rerollType)
+	faceProbs := resolveRerolls(bs, hitModifier, criticalThreshold, rerollType)
 
 	for face := 1; face <= 6; face++ {
 		prob := faceProbs[face]
@@ -51,15 +107,17 @@ func CalculateSingleHitDistribution(
 			continue
 		}
 
-		outcome := resolveDieOutcome(face, bs, hitModifier, criticalThreshold, lethalHits, sustainedHits)
-		dist[outcome] += prob
+		for outcome, pOutcome := range resolveDieOutcome(face, bs, hitModifier, criticalThreshold, effects) {
+			dist[outcome] += prob * pOutcome
+		}
 	}
 
 	return dist
 }
 
-// Determine what happens on a specific physical die roll.
-func resolveDieOutcome(face int, bs int, mod int, critThreshold int, lethal bool, sustained int) HitOutcome {
+// Determine what happens on a specific physical die roll. The result is a
+// PMF because a Critical Hit may score a random number of extra hits.
+func resolveDieOutcome(face int, bs int, mod int, critThreshold int, effects CriticalHitEffects) map[HitOutcome]float64 {
 	// Critical Hits are usually based on Unmodified rolls in 10th
 	isCrit := face >= critThreshold // e.g., 6 >= 6
 
@@ -70,23 +128,30 @@ func resolveDieOutcome(face int, bs int, mod int, critThreshold int, lethal bool
 	isHit := isCrit || (modRoll >= bs && face != 1)
 
 	if !isHit {
-		return HitOutcome{NormalHits: 0, LethalHits: 0}
+		return map[HitOutcome]float64{{}: 1.0}
+	}
+	if !isCrit {
+		return map[HitOutcome]float64{{NormalHits: 1}: 1.0}
 	}
 
 	outcome := HitOutcome{}
-
-	if isCrit && lethal {
+	switch {
+	case effects.AutoWound:
 		outcome.LethalHits = 1
-	} else {
+	case effects.tracksCriticalHits():
+		outcome.CriticalHits = 1
+	default:
 		outcome.NormalHits = 1
 	}
 
 	// Sustained hits are treated as Normal Hits; they don't trigger Lethals recursively.
-	if isCrit && sustained > 0 {
-		outcome.NormalHits += sustained
+	dist := make(map[HitOutcome]float64)
+	for extra, p := range effects.extraHitsDist() {
+		o := outcome
+		o.NormalHits += extra
+		dist[o] += p
 	}
-
-	return outcome
+	return dist
 }
 
 // clampToD6Range clamps a modified roll into the valid [1, 6] die range.
@@ -101,7 +166,40 @@ func clampToD6Range(v int) int {
 }
 
 // Reroll logic: computes P(Face) for each die face under rerollType.
-func resolveRerolls(bs, mod int, reroll RerollType) map[int]float64 {
+func resolveRerolls(bs, mod, critThreshold int, reroll RerollType) map[int]float64 {
+	failed, critical := hitFaceChecks(bs, mod, critThreshold)
+	return rerollFaces(reroll, failed, critical)
+}
+
+// singleHitRerollEligible returns the chance that a hit roll misses
+// without having been rerolled, so that a single-die reroll can be spent
+// on it (see singleRerollEligible).
+func singleHitRerollEligible(bs, mod, critThreshold int, reroll RerollType) float64 {
+	failed, critical := hitFaceChecks(bs, mod, critThreshold)
+	return singleRerollEligible(reroll, failed, critical)
+}
+
+// hitFaceChecks returns whether a hit roll face misses and whether it is
+// a Critical Hit.
+func hitFaceChecks(bs, mod, critThreshold int) (failed, critical func(face int) bool) {
+	failed = func(face int) bool {
+		// Fail check: Nat 1 or Modified < BS, unless it is a Critical Hit
+		modRoll := clampToD6Range(face + mod)
+		return face < critThreshold && (face == 1 || modRoll < bs)
+	}
+	critical = func(face int) bool {
+		return face >= critThreshold
+	}
+	return failed, critical
+}
+
+// rerollFaces computes P(Face) for each die face when the faces picked by
+// rerollType are rerolled once: RerollOnes rerolls a natural 1, RerollFail
+// every face for which failed is true, and RerollNonCritical every face for
+// which critical is false. RerollOptimal is chosen by the engine before
+// the dice are rolled (see chooseOptimalRerolls) and falls back to
+// RerollFail here.
+func rerollFaces(reroll RerollType, failed, critical func(face int) bool) map[int]float64 {
 	probs := make(map[int]float64)
 	base := 1.0 / 6.0
 
@@ -114,21 +212,10 @@ func resolveRerolls(bs, mod int, reroll RerollType) map[int]float64 {
 	}
 
 	rerollPool := 0.0
-	shouldReroll := func(face int) bool {
-		if reroll == RerollOnes {
-			return face == 1
-		}
-		if reroll == RerollFail {
-			// Fail check: Nat 1 or Modified < BS
-			modRoll := clampToD6Range(face + mod)
-			return face == 1 || modRoll < bs
-		}
-		return false
-	}
 
 	// Harvest probability from rerolled faces
 	for i := 1; i <= 6; i++ {
-		if shouldReroll(i) {
+		if rerollsFace(reroll, failed, critical, i) {
 			rerollPool += probs[i]
 			probs[i] = 0
 		}
@@ -142,3 +229,31 @@ func resolveRerolls(bs, mod int, reroll RerollType) map[int]float64 {
 
 	return probs
 }
+
+// rerollsFace reports whether rerollType rerolls the given face (see
+// rerollFaces).
+func rerollsFace(reroll RerollType, failed, critical func(face int) bool, face int) bool {
+	switch reroll {
+	case RerollOnes:
+		return face == 1
+	case RerollFail, RerollOptimal:
+		return failed(face)
+	case RerollNonCritical:
+		return !critical(face)
+	}
+	return false
+}
+
+// singleRerollEligible returns the chance that a die ends up failed
+// without having been rerolled under rerollType: the dice a single-die
+// reroll (see SingleRerolls) can still be spent on. A die can never be
+// rerolled twice.
+func singleRerollEligible(reroll RerollType, failed, critical func(face int) bool) float64 {
+	eligible := 0.0
+	for face := 1; face <= 6; face++ {
+		if failed(face) && !rerollsFace(reroll, failed, critical, face) {
+			eligible += 1.0 / 6.0
+		}
+	}
+	return eligible
+}
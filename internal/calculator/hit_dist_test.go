@@ -261,11 +261,42 @@ func TestCalculateSingleHitDistribution(t *testing.T) {
 				{NormalHits: 1, LethalHits: 0}: 4.0 / 6.0,
 			},
 		},
+		{
+			name:              "BS 3+, Reroll Non-Critical, Lethal",
+			bs:                3,
+			rerollType:        RerollNonCritical,
+			hitModifier:       0,
+			lethalHits:        true,
+			sustainedHits:     0,
+			criticalThreshold: 6,
+			expectedDist: map[HitOutcome]float64{
+				// Faces 1-5 are all rerolled: each face gains 5/36.
+				{NormalHits: 0, LethalHits: 0}: 10.0 / 36.0,
+				{NormalHits: 1, LethalHits: 0}: 15.0 / 36.0,
+				{NormalHits: 0, LethalHits: 1}: 11.0 / 36.0,
+			},
+		},
+		{
+			// Critical Hits on 5+ with BS 6+: the 5 is a Critical Hit, so
+			// rerolling failures only rerolls 1-4 (each face gains 4/36).
+			name:              "BS 6+, Crit 5+, Reroll Fail keeps Critical Hits",
+			bs:                6,
+			rerollType:        RerollFail,
+			hitModifier:       0,
+			lethalHits:        false,
+			sustainedHits:     0,
+			criticalThreshold: 5,
+			expectedDist: map[HitOutcome]float64{
+				{NormalHits: 0, LethalHits: 0}: 16.0 / 36.0,
+				{NormalHits: 1, LethalHits: 0}: 20.0 / 36.0,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotDist := CalculateSingleHitDistribution(tt.bs, tt.rerollType, tt.hitModifier, tt.lethalHits, tt.sustainedHits, tt.criticalThreshold)
+			gotDist := CalculateSingleHitDistribution(tt.bs, tt.rerollType, tt.hitModifier,
+				CriticalHitEffects{AutoWound: tt.lethalHits, ExtraHits: DiceRoll{Modifier: tt.sustainedHits}}, tt.criticalThreshold)
 
 			// Verify distribution matches
 			for outcome, expectedProb := range tt.expectedDist {
@@ -286,3 +317,61 @@ func TestCalculateSingleHitDistribution(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateSingleHitDistribution_CriticalHitEffects(t *testing.T) {
+	tests := []struct {
+		name         string
+		effects      CriticalHitEffects
+		expectedDist map[HitOutcome]float64
+	}{
+		{
+			// BS 3+: 1-2 miss, 3-5 hit, the 6 scores 1 + D3 hits.
+			name:    "Sustained Hits D3",
+			effects: CriticalHitEffects{ExtraHits: DiceRoll{Count: 1, Sides: 3}},
+			expectedDist: map[HitOutcome]float64{
+				{}:              2.0 / 6.0,
+				{NormalHits: 1}: 3.0 / 6.0,
+				{NormalHits: 2}: 1.0 / 18.0,
+				{NormalHits: 3}: 1.0 / 18.0,
+				{NormalHits: 4}: 1.0 / 18.0,
+			},
+		},
+		{
+			name:    "Critical Hit with its own Strength and Sustained Hits 1",
+			effects: CriticalHitEffects{ExtraHits: DiceRoll{Modifier: 1}, Strength: 8},
+			expectedDist: map[HitOutcome]float64{
+				{}:                               2.0 / 6.0,
+				{NormalHits: 1}:                  3.0 / 6.0,
+				{NormalHits: 1, CriticalHits: 1}: 1.0 / 6.0,
+			},
+		},
+		{
+			// An auto-wound needs no Strength; its AP bonus is tracked
+			// by the wound streams.
+			name:    "Lethal Hits with an AP bonus",
+			effects: CriticalHitEffects{AutoWound: true, APBonus: 1},
+			expectedDist: map[HitOutcome]float64{
+				{}:              2.0 / 6.0,
+				{NormalHits: 1}: 3.0 / 6.0,
+				{LethalHits: 1}: 1.0 / 6.0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDist := CalculateSingleHitDistribution(3, RerollNone, 0, tt.effects, 6)
+
+			for outcome, expectedProb := range tt.expectedDist {
+				if math.Abs(gotDist[outcome]-expectedProb) > epsilon {
+					t.Errorf("Outcome %+v: expected prob %.5f, got %.5f", outcome, expectedProb, gotDist[outcome])
+				}
+			}
+			for outcome, gotProb := range gotDist {
+				if _, ok := tt.expectedDist[outcome]; !ok && gotProb > epsilon {
+					t.Errorf("Unexpected outcome %+v with prob %.5f", outcome, gotProb)
+				}
+			}
+		})
+	}
+}
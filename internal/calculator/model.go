@@ -28,8 +28,34 @@ import (
 // CombatSimulationRequest is the clean "Internal" struct
 type CombatSimulationRequest struct {
 	Attacker AttackerProfile
+	// Weapons are further profiles fired by the same unit, each with its
+	// own model count. They are resolved after Attacker, in order, against
+	// the same target.
+	Weapons  []AttackerProfile
 	Target   TargetProfile
 	Settings SimulationSettings
+	// Thresholds are the results whose odds SimulationResult reports.
+	Thresholds ResultThresholds
+	// Points prices the attacking unit and the target's models.
+	Points UnitPoints
+}
+
+// UnitPoints are the costs the points results are measured in. Either
+// may be zero when unknown.
+type UnitPoints struct {
+	// Attacker is the cost of the whole attacking unit, every profile
+	// included.
+	Attacker int
+	// TargetPerModel is the cost of one model of the target. Every model
+	// is priced the same, Groups included.
+	TargetPerModel int
+}
+
+// ResultThresholds asks for P(destroyed >= k) for each k of Destroyed,
+// and P(damage >= d) for each d of Damage.
+type ResultThresholds struct {
+	Destroyed []int
+	Damage    []int
 }
 
 type AttackerProfile struct {
@@ -44,6 +70,82 @@ type AttackerProfile struct {
 	LethalHits        bool
 	DevastatingWounds bool
 	Torrent           bool
+	Anti              []AntiKeyword
+	// MeltaX and RapidFireX only apply when Settings.HalfRange is set.
+	MeltaX     int
+	RapidFireX int
+	// Heavy, Lance and IndirectFire depend on the situational toggles in
+	// SimulationSettings; TwinLinked and IgnoresCover always apply.
+	Heavy        bool
+	Lance        bool
+	TwinLinked   bool
+	IgnoresCover bool
+	IndirectFire bool
+	// HitModifier and WoundModifier apply to this profile only, on top of
+	// the modifiers in SimulationSettings. They count as one unnamed
+	// source alongside HitModifiers and WoundModifiers.
+	HitModifier    int
+	WoundModifier  int
+	HitModifiers   []Modifier
+	WoundModifiers []Modifier
+	// IgnoreHitModifiers and IgnoreWoundModifiers are abilities that let
+	// the attacker ignore any or all modifiers to those rolls; the engine
+	// ignores every penalty and keeps the bonuses.
+	IgnoreHitModifiers   bool
+	IgnoreWoundModifiers bool
+	// MortalWounds are inflicted on the target, in addition to the
+	// attack's normal effect, by each critical result of the
+	// MortalWoundsOn roll. A fixed count is a DiceRoll with only a Modifier.
+	MortalWounds   DiceRoll
+	MortalWoundsOn MortalWoundTrigger
+	// LegacyDevastatingWounds uses the original [DEVASTATING WOUNDS]
+	// wording: a Critical Wound inflicts mortal wounds equal to the
+	// Damage characteristic and the attack sequence ends.
+	LegacyDevastatingWounds bool
+	// Precision attacks are allocated to the target's designated
+	// Character group first, while any of its models are alive.
+	Precision bool
+	// DamageOptions change how each Damage roll is made.
+	DamageOptions DiceOptions
+	// AttacksOptions change how each Attacks roll is made.
+	AttacksOptions DiceOptions
+	// AttacksRolledPerUnit rolls the Attacks characteristic once for the
+	// whole unit: every model makes the number of attacks rolled.
+	AttacksRolledPerUnit bool
+	// SustainedHitsDice is a variable [SUSTAINED HITS] value, e.g. D3. It
+	// is added to SustainedHits.
+	SustainedHitsDice DiceRoll
+	// CriticalHitStrength, if positive, is the Strength a Critical Hit
+	// rolls to wound with, and CriticalHitAPBonus improves its AP. They
+	// apply to the hit scored by the Critical Hit, not to extra hits.
+	CriticalHitStrength int
+	CriticalHitAPBonus  int
+	// CriticalWoundAPBonus improves the AP, and CriticalWoundDamageBonus
+	// the Damage, of an attack that scores a Critical Wound. They stack
+	// with CriticalHitAPBonus. The damage bonus also applies to
+	// devastating wounds, which are always Critical Wounds.
+	CriticalWoundAPBonus     int
+	CriticalWoundDamageBonus int
+	// Hazardous models take a Hazardous test after firing (see
+	// hazardousDestroyedDist). WoundsPerModel and Keywords describe the
+	// attacking models for it.
+	Hazardous      bool
+	WoundsPerModel int
+	Keywords       []string
+}
+
+// Modifier is one named source of a modifier to a dice roll, e.g.
+// {Source: "Heavy", Value: 1}.
+type Modifier struct {
+	Source string
+	Value  int
+}
+
+// AntiKeyword is a single [ANTI-KEYWORD X+] weapon ability: against a target
+// with Keyword, an unmodified wound roll of Threshold+ is a Critical Wound.
+type AntiKeyword struct {
+	Keyword   string
+	Threshold int
 }
 
 type TargetProfile struct {
@@ -52,11 +154,80 @@ type TargetProfile struct {
 	Save           int
 	Invulnerable   *int
 	WoundsPerModel int
-	FeelNoPain     *int
+	// FeelNoPain is the general Feel No Pain roll, used against all damage.
+	FeelNoPain *int
+	// MortalFeelNoPain is a Feel No Pain roll usable only against mortal
+	// wounds. Devastating wound damage is not a mortal wound.
+	MortalFeelNoPain *int
+	// FeelNoPainExcludesDevastating stops FeelNoPain from being used
+	// against devastating wound damage.
+	FeelNoPainExcludesDevastating bool
+	// DamageReduction is subtracted from, and HalveDamage halves, the
+	// Damage characteristic of each attack allocated to the target.
+	DamageReduction int
+	HalveDamage     bool
+	// DamageReductionExcludesDevastating stops DamageReduction and
+	// HalveDamage from applying to devastating wounds.
+	DamageReductionExcludesDevastating bool
+	Keywords                           []string
+	// Stealth gives attacks against the target -1 to hit, and
+	// MinusOneToWoundIfStronger gives -1 to wound to attacks whose
+	// Strength is greater than its Toughness. Both stack with the
+	// attacker's modifiers under the +1/-1 cap.
+	Stealth                   bool
+	MinusOneToWoundIfStronger bool
+	// APReduction worsens the AP of each attack by that much, to a
+	// minimum of 0 (e.g. 1 for Armour of Contempt).
+	APReduction int
+	// WoundRollsFailUpTo makes unmodified wound rolls up to that value
+	// fail, whatever the attacker's abilities (e.g. 3 for Transhuman
+	// Physiology). 0 for none.
+	WoundRollsFailUpTo int
+	// Groups describes a unit of mixed models, e.g. a squad with a
+	// sergeant or a Bodyguard unit with an attached Leader. When set, it
+	// replaces Count, WoundsPerModel, Save, Invulnerable and FeelNoPain.
+	// Saves are then rolled against the model allocated to, so PenDist
+	// uses the saves of the group allocated to first. DamageDist is the
+	// damage dealt either way; WoundsLostDist drops the damage in excess
+	// of each model's wounds.
+	Groups []ModelGroup
+	// PrecisionTarget names the Character group that Precision attacks
+	// are allocated to first. Empty designates the first Character group.
+	PrecisionTarget string
+	// WoundsRemaining, if set, is the wounds the unit has left before the
+	// attack, e.g. after an earlier shooting attack. The wounds lost are
+	// taken in allocation order. DestroyedDist and the other results then
+	// count the models destroyed by this attack only.
+	WoundsRemaining *int
+	// WoundsRemainingDist, if set instead, is a PMF of the wounds the unit
+	// has left before the attack, shaped like a RemainingWoundsDist: zero
+	// is a unit already destroyed. The attack is resolved from each of
+	// them, knowing which one it is, and the results are weighted by
+	// their probabilities.
+	WoundsRemainingDist map[int]float64
 
 	HasCover bool
 }
 
+// ModelGroup is a set of identical models within a target unit.
+type ModelGroup struct {
+	Name           string
+	Count          int
+	WoundsPerModel int
+	Save           int
+	Invulnerable   *int
+	FeelNoPain     *int
+	// Character groups are allocated attacks only once every other model
+	// in the unit is destroyed.
+	Character bool
+}
+
+// GroupResult is the share of one ModelGroup in a SimulationResult.
+type GroupResult struct {
+	Name          string
+	DestroyedDist map[int]float64
+}
+
 type SimulationSettings struct {
 	HitReroll              RerollType
 	WoundReroll            RerollType
@@ -64,8 +235,49 @@ type SimulationSettings struct {
 	CriticalHitThreshold   int
 	CriticalWoundThreshold int
 	SaveModifier           int
-	HitModifier            int
-	WoundModifier          int
+	// HitModifier and WoundModifier are the net modifiers to the hit and
+	// wound rolls. applyWeaponAbilities resolves them from every source,
+	// capped at +1/-1; a value set here counts as one unnamed source.
+	HitModifier   int
+	WoundModifier int
+	// HitModifiers and WoundModifiers are the situational sources shared
+	// by every profile, e.g. auras or the target's Stealth.
+	HitModifiers   []Modifier
+	WoundModifiers []Modifier
+	// HalfRange marks the target as within half the weapon's range,
+	// activating [MELTA X] and [RAPID FIRE X].
+	HalfRange bool
+	// RemainedStationary activates [HEAVY].
+	RemainedStationary bool
+	// Charged activates [LANCE].
+	Charged bool
+	// TargetNotVisible activates the [INDIRECT FIRE] penalties.
+	TargetNotVisible bool
+	// FiringMode is how the attacks are made, e.g. Fire Overwatch.
+	FiringMode FiringMode
+	// SingleReroll grants one extra reroll of a single die per stage of
+	// the attack sequence, e.g. a Command Re-roll. A request with Weapons
+	// and the activations of a SequenceRequest cannot use it.
+	SingleReroll SingleRerolls
+}
+
+// SingleRerolls selects the stages in which one die may be rerolled. The
+// die chosen is one that no other reroll applies to:
+//   - Hit, Wound: a failed roll;
+//   - Save: a failed saving throw of a normal wound (against Groups,
+//     not together with a single Damage reroll);
+//   - Damage: the first Damage roll of a normal or devastating wound that
+//     is below the average of the dice, unless the profile rerolls its
+//     Damage rolls with a DiceOptions policy;
+//   - Attacks: the lowest Attacks die of the unit, if below average,
+//     unless the profile rerolls its Attacks rolls with a DiceOptions
+//     policy.
+type SingleRerolls struct {
+	Hit     bool
+	Wound   bool
+	Save    bool
+	Damage  bool
+	Attacks bool
 }
 
 // dice string struct - 2d6 + 1
@@ -75,6 +287,74 @@ type DiceRoll struct {
 	Modifier int // Flat bonus (e.g., +1)
 }
 
+// DiceOptions are the rules that change how a characteristic's dice are
+// rolled, e.g. "re-roll the Damage roll" or "Damage D6, minimum 3". The
+// zero value rolls the dice once.
+type DiceOptions struct {
+	Reroll DiceReroll
+	// RerollBelow is the result below which DiceRerollBelow rerolls.
+	RerollBelow int
+	// BestOf rolls the dice this many times and keeps the highest result.
+	// Values below 2 roll once.
+	BestOf int
+	// Minimum is the lowest result the roll can produce. It is applied
+	// before any modifier to the characteristic.
+	Minimum int
+}
+
+// DiceReroll selects which rolls of a characteristic are rerolled. A
+// rerolled roll is never rerolled again.
+type DiceReroll int
+
+const (
+	DiceRerollNone DiceReroll = iota
+	// DiceRerollBelowAverage rerolls every roll whose dice are below
+	// their average, e.g. a 1, 2 or 3 on a D6.
+	DiceRerollBelowAverage
+	// DiceRerollBelow rerolls every result below DiceOptions.RerollBelow.
+	DiceRerollBelow
+	// DiceRerollSingle rerolls one roll per volley, like the matching
+	// SingleRerolls stage but without being shared with other profiles.
+	DiceRerollSingle
+)
+
+// String implements the fmt.Stringer interface to provide a readable string value.
+func (r DiceReroll) String() string {
+	name, ok := diceRerollNames[r]
+	if !ok {
+		return fmt.Sprintf("UnknownDiceReroll(%d)", r)
+	}
+	return name
+}
+
+var diceRerollNames = map[DiceReroll]string{
+	DiceRerollNone:         "none",
+	DiceRerollBelowAverage: "below_average",
+	DiceRerollBelow:        "below",
+	DiceRerollSingle:       "single",
+}
+
+// MarshalJSON serializes the policy as its string value (e.g., "below").
+func (r DiceReroll) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON converts a string value (e.g., "below_average") back into the
+// DiceReroll constant.
+func (r *DiceReroll) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for k, v := range diceRerollNames {
+		if v == s {
+			*r = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown DiceReroll: %s", s)
+}
+
 // RerollType enumerates the supported dice-reroll rules.
 type RerollType int
 
@@ -83,6 +363,13 @@ const (
 	RerollNone RerollType = iota
 	RerollOnes
 	RerollFail
+	// RerollNonCritical rerolls every hit or wound roll that is not a
+	// critical result, successes included, to fish for criticals.
+	RerollNonCritical
+	// RerollOptimal lets the engine pick, per weapon profile, whichever of
+	// RerollFail and RerollNonCritical destroys the most models on
+	// average.
+	RerollOptimal
 )
 
 // String implements the fmt.Stringer interface to provide a readable string value.
@@ -97,6 +384,9 @@ var rerollTypeNames = map[RerollType]string{
 	RerollNone: "none",
 	RerollOnes: "ones",
 	RerollFail: "fail",
+
+	RerollNonCritical: "non_crit",
+	RerollOptimal:     "optimal",
 }
 
 // MarshalJSON ensures that the RerollType is serialized as its string value (e.g., "ones") in the JSON response,
@@ -121,6 +411,98 @@ func (r *RerollType) UnmarshalJSON(b []byte) error {
 	return fmt.Errorf("unknown RerollType: %s", s)
 }
 
+// FiringMode is how a unit makes its attacks.
+type FiringMode int
+
+const (
+	FiringModeNormal FiringMode = iota
+	// FiringModeOverwatch is Fire Overwatch: an attack only hits on a
+	// Critical Hit, whatever its BS and modifiers, unless it has
+	// [TORRENT].
+	FiringModeOverwatch
+)
+
+// String implements the fmt.Stringer interface to provide a readable string value.
+func (f FiringMode) String() string {
+	name, ok := firingModeNames[f]
+	if !ok {
+		return fmt.Sprintf("UnknownFiringMode(%d)", f)
+	}
+	return name
+}
+
+var firingModeNames = map[FiringMode]string{
+	FiringModeNormal:    "normal",
+	FiringModeOverwatch: "overwatch",
+}
+
+// MarshalJSON serializes the mode as its string value (e.g., "overwatch").
+func (f FiringMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON converts a string value (e.g., "overwatch") back into the
+// FiringMode constant.
+func (f *FiringMode) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for k, v := range firingModeNames {
+		if v == s {
+			*f = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown FiringMode: %s", s)
+}
+
+// MortalWoundTrigger selects the roll whose critical results inflict an
+// attacker's MortalWounds.
+type MortalWoundTrigger int
+
+const (
+	MortalWoundsNever MortalWoundTrigger = iota
+	MortalWoundsOnCriticalHit
+	MortalWoundsOnCriticalWound
+)
+
+// String implements the fmt.Stringer interface to provide a readable string value.
+func (m MortalWoundTrigger) String() string {
+	name, ok := mortalWoundTriggerNames[m]
+	if !ok {
+		return fmt.Sprintf("UnknownMortalWoundTrigger(%d)", m)
+	}
+	return name
+}
+
+var mortalWoundTriggerNames = map[MortalWoundTrigger]string{
+	MortalWoundsNever:           "none",
+	MortalWoundsOnCriticalHit:   "critical_hit",
+	MortalWoundsOnCriticalWound: "critical_wound",
+}
+
+// MarshalJSON serializes the trigger as its string value (e.g., "critical_hit").
+func (m MortalWoundTrigger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON converts a string value (e.g., "critical_wound") back into
+// the MortalWoundTrigger constant.
+func (m *MortalWoundTrigger) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for k, v := range mortalWoundTriggerNames {
+		if v == s {
+			*m = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown MortalWoundTrigger: %s", s)
+}
+
 type SimulationResult struct {
 	AverageHits      float64
 	AverageDestroyed float64
@@ -128,5 +510,95 @@ type SimulationResult struct {
 	WoundDist        map[int]float64
 	PenDist          map[int]float64 // Armor saves failed
 	DamageDist       map[int]float64 // Total damage after failed saves + FNP
-	DestroyedDist    map[int]float64
+	// WoundsLostDist is the wounds the target lost: DamageDist without
+	// the damage in excess of each model's wounds and without the damage
+	// dealt once the unit is destroyed.
+	WoundsLostDist  map[int]float64
+	DestroyedDist   map[int]float64
+	MortalWoundDist map[int]float64 // Mortal wounds inflicted, before FNP
+	// Stats summarizes HitDist, WoundDist, PenDist, DamageDist and
+	// DestroyedDist.
+	Stats DistributionStats
+	// DestroyedAtLeast and DamageAtLeast map each of the request's
+	// Thresholds to the probability of reaching it, and WipeProb is the
+	// probability that every model of the target is destroyed. They are
+	// read off the pruned DestroyedDist and DamageDist, so each may be low
+	// by up to DestroyedTailMass or DamageTailMass: the probability
+	// pruning dropped from that distribution.
+	DestroyedAtLeast  map[int]float64
+	DamageAtLeast     map[int]float64
+	WipeProb          float64
+	DestroyedTailMass float64
+	DamageTailMass    float64
+	// RemainingWoundsDist is the total wounds the target has left after
+	// the attack, and WoundedModelDist the wounds left on the model the
+	// next attack would be allocated to: the wounded model if there is
+	// one, 0 if the unit is destroyed.
+	RemainingWoundsDist map[int]float64
+	WoundedModelDist    map[int]float64
+	// Groups reports DestroyedDist per Target.Groups entry, in request
+	// order. Nil when the target has no Groups.
+	Groups []GroupResult
+	// CharacterDestroyedProb is the probability that every model of the
+	// designated Character group (see TargetProfile.PrecisionTarget) is
+	// destroyed. Zero when the target has no Character group.
+	CharacterDestroyedProb float64
+	// EffectiveHitModifier and EffectiveWoundModifier are the capped net
+	// modifiers the Attacker profile rolled with; each entry of Weapons
+	// reports its own.
+	EffectiveHitModifier   int
+	EffectiveWoundModifier int
+	// SelfDestroyedDist is the number of attacking models destroyed by
+	// their own [HAZARDOUS] weapons.
+	SelfDestroyedDist map[int]float64
+	// PointsDestroyedDist is DestroyedDist priced at
+	// Points.TargetPerModel, ExpectedPointsDestroyed its mean, and
+	// PointsPer100 the expected points destroyed per 100 points of the
+	// attacking unit. Each entry of Weapons is measured against the cost
+	// of the whole unit. Nil and zero when the points are unknown.
+	PointsDestroyedDist     map[int]float64
+	ExpectedPointsDestroyed float64
+	PointsPer100            float64
+	// Weapons breaks a multi-profile volley down per profile (Attacker
+	// first), each as if it had fired alone at the target before the
+	// attack. Nil for a single profile.
+	Weapons []SimulationResult
+}
+
+// SequenceRequest is several units activating one after another against
+// the same target. Each activation fires at the wound states the previous
+// ones left behind.
+type SequenceRequest struct {
+	Activations []Activation
+	Target      TargetProfile
+}
+
+// Activation is one unit's attack in a SequenceRequest: the same profiles
+// and settings a CombatSimulationRequest takes, without the target.
+type Activation struct {
+	Attacker AttackerProfile
+	Weapons  []AttackerProfile
+	Settings SimulationSettings
+}
+
+// SequenceResult reports a SequenceRequest activation by activation.
+// Activations after the one that is certain to wipe the target are not
+// run, so Steps may be shorter than the request's Activations.
+type SequenceResult struct {
+	Steps []SequenceStep
+	// DestroyedDist and WipeProb are those of the last step run.
+	DestroyedDist map[int]float64
+	WipeProb      float64
+}
+
+// SequenceStep is the outcome of one activation of a sequence.
+type SequenceStep struct {
+	// DestroyedDist is the number of models this activation destroyed,
+	// and CumulativeDestroyedDist the number destroyed since the first.
+	DestroyedDist           map[int]float64
+	CumulativeDestroyedDist map[int]float64
+	// WipeProb is the probability that the target is wiped by the end of
+	// this activation.
+	WipeProb            float64
+	RemainingWoundsDist map[int]float64
 }
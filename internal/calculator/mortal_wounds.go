@@ -0,0 +1,470 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import (
+	"math"
+	"sort"
+)
+
+// woundClass tells apart the wounds that roll saving throws by the
+// critical effects that change their AP or Damage. It is a set of the
+// flags below; a flag is only set if the profile has such an effect, so
+// wounds without one stay in the normal class (0).
+type woundClass int
+
+const (
+	// criticalHitClass marks the hit scored by a Critical Hit with an AP
+	// bonus.
+	criticalHitClass woundClass = 1 << iota
+	// criticalWoundClass marks a Critical Wound with an AP or Damage
+	// bonus.
+	criticalWoundClass
+
+	numWoundClasses = 4
+)
+
+// woundStreams counts the wounds an attack (or a whole volley) sends to
+// allocation through each stream. Saveable wounds are counted per
+// woundClass before saving throws; devastating and mortal wounds bypass
+// them.
+type woundStreams struct {
+	Saveable    [numWoundClasses]int
+	Devastating int
+	Mortal      int
+}
+
+// saveable returns the number of wounds that roll saving throws.
+func (s woundStreams) saveable() int {
+	n := 0
+	for _, c := range s.Saveable {
+		n += c
+	}
+	return n
+}
+
+// tracksCriticalWounds reports whether Critical Wounds that roll saving
+// throws must be kept apart from other wounds, because they have an AP or
+// Damage bonus.
+func tracksCriticalWounds(a AttackerProfile) bool {
+	if a.DevastatingWounds || a.LegacyDevastatingWounds {
+		return false
+	}
+	return a.CriticalWoundAPBonus > 0 || a.CriticalWoundDamageBonus > 0
+}
+
+// classAP returns the AP of the wounds of a class.
+func classAP(a AttackerProfile, class woundClass) int {
+	ap := a.AP
+	if class&criticalHitClass != 0 {
+		ap += a.CriticalHitAPBonus
+	}
+	if class&criticalWoundClass != 0 {
+		ap += a.CriticalWoundAPBonus
+	}
+	return ap
+}
+
+// damageClass returns the class whose damage the wounds of class deal:
+// the AP bonus of a Critical Hit only changes the saving throw.
+func (class woundClass) damageClass() woundClass {
+	return class &^ criticalHitClass
+}
+
+// hasMortalWoundSource reports whether the attacker can inflict mortal
+// wounds, which needs the per-attack stream PMF instead of the dense
+// (normal, devastating) wound matrix.
+func hasMortalWoundSource(a AttackerProfile) bool {
+	return a.LegacyDevastatingWounds || a.MortalWoundsOn != MortalWoundsNever
+}
+
+// woundRolls splits a wound roll into a non-critical wound, a critical
+// wound or a failure. The three need not sum to 1.
+type woundRolls struct {
+	nonCrit, crit, fail float64
+}
+
+// criticalHitWoundRolls returns the wound rolls of the hits scored by
+// Critical Hits, at the Strength of the profile's CriticalHitEffects if
+// it has one. A single wound reroll is never spent on them.
+func criticalHitWoundRolls(req CombatSimulationRequest) woundRolls {
+	if s := criticalHitEffects(req.Attacker).Strength; s > 0 {
+		req.Attacker.Strength = s
+	}
+	nonCrit, crit := splitWoundOutcomes(computeWoundOutcomeDist(req), true)
+	return woundRolls{nonCrit: nonCrit, crit: crit, fail: 1.0 - nonCrit - crit}
+}
+
+// singleAttackWoundStreams returns the PMF of wound streams produced by one
+// attack. probNonCritWound and probCritWound split a successful wound roll
+// into its non-critical and critical parts.
+func singleAttackWoundStreams(req CombatSimulationRequest, probNonCritWound, probCritWound float64) map[woundStreams]float64 {
+	return attackWoundStreams(req, streamHitResults(req, req.Settings.HitReroll),
+		woundRolls{nonCrit: probNonCritWound, crit: probCritWound, fail: 1.0 - probNonCritWound - probCritWound},
+		criticalHitWoundRolls(req))
+}
+
+// hitResult is the outcome of one hit roll, and whether it was a Critical
+// Hit.
+type hitResult struct {
+	outcome HitOutcome
+	crit    bool
+}
+
+// streamHitResults returns the PMF of the hit result of one attack, with
+// the given hit reroll.
+func streamHitResults(req CombatSimulationRequest, reroll RerollType) map[hitResult]float64 {
+	a := req.Attacker
+	hits := make(map[hitResult]float64)
+	if a.Torrent {
+		hits[hitResult{outcome: HitOutcome{NormalHits: 1}}] = 1.0
+		return hits
+	}
+
+	effects := criticalHitEffects(a)
+	faceProbs := resolveRerolls(a.BS, req.Settings.HitModifier, req.Settings.CriticalHitThreshold, reroll)
+	for face := 1; face <= 6; face++ {
+		prob := faceProbs[face]
+		if prob == 0 {
+			continue
+		}
+		outcomes := resolveDieOutcome(face, a.BS, req.Settings.HitModifier, req.Settings.CriticalHitThreshold, effects)
+		for outcome, pOutcome := range outcomes {
+			hits[hitResult{outcome: outcome, crit: face >= req.Settings.CriticalHitThreshold}] += prob * pOutcome
+		}
+	}
+	return hits
+}
+
+// attackWoundStreams returns the PMF of wound streams produced by an
+// attack whose hit result has the PMF hits. Normal hits roll to wound
+// with normal, and the critical hits of HitOutcome with critical.
+func attackWoundStreams(req CombatSimulationRequest, hits map[hitResult]float64, normal, critical woundRolls) map[woundStreams]float64 {
+	a := req.Attacker
+	effects := criticalHitEffects(a)
+	noMortals := map[int]float64{0: 1.0}
+
+	critHitMortals, critWoundMortals := noMortals, noMortals
+	if effects.MortalWounds != nil {
+		critHitMortals = generateDiceDistribution(*effects.MortalWounds, DiceOptions{})
+	}
+	if a.MortalWoundsOn == MortalWoundsOnCriticalWound {
+		critWoundMortals = generateDiceDistribution(a.MortalWounds, DiceOptions{})
+	}
+	if a.LegacyDevastatingWounds {
+		// Old wording: the Critical Wound becomes mortal wounds equal to
+		// the Damage characteristic instead of a wound.
+		legacy := _calculateDamageDistribution(a.Damage, a.DamageOptions,
+			damageModifiers{Bonus: halfRangeBonus(a.MeltaX, req.Settings.HalfRange) + a.CriticalWoundDamageBonus}, nil)
+		critWoundMortals = convolveDist(critWoundMortals, legacy)
+	}
+
+	// The wounds of critical hits only need their own class if they are
+	// saved differently.
+	var critHitClass woundClass
+	if effects.APBonus > 0 {
+		critHitClass = criticalHitClass
+	}
+
+	dist := make(map[woundStreams]float64)
+	for hit, pHit := range hits {
+		// Lethal Hits are always the Critical Hit itself, and are not
+		// Critical Wounds.
+		var lethal woundStreams
+		lethal.Saveable[critHitClass] = hit.outcome.LethalHits
+		hitDist := make(map[woundStreams]float64)
+		mortals := noMortals
+		if hit.crit {
+			mortals = critHitMortals
+		}
+		for m, pM := range mortals {
+			s := lethal
+			s.Mortal = m
+			hitDist[s] += pM
+		}
+
+		hitDist = convolveWoundStreams(hitDist,
+			hitWoundStreams(a, hit.outcome.NormalHits, normal, critWoundMortals, 0))
+		if hit.outcome.CriticalHits > 0 {
+			hitDist = convolveWoundStreams(hitDist,
+				hitWoundStreams(a, hit.outcome.CriticalHits, critical, critWoundMortals, critHitClass))
+		}
+		for s, p := range hitDist {
+			dist[s] += pHit * p
+		}
+	}
+	return dist
+}
+
+// hitWoundStreams returns the streams of n hits of the given class
+// rolling to wound with rolls. Saveable Critical Wounds join the
+// criticalWoundClass if the profile tracks them.
+func hitWoundStreams(a AttackerProfile, n int, rolls woundRolls, critWoundMortals map[int]float64, class woundClass) map[woundStreams]float64 {
+	critClass := class
+	if tracksCriticalWounds(a) {
+		critClass |= criticalWoundClass
+	}
+	dist := make(map[woundStreams]float64)
+	for c := 0; c <= n; c++ {
+		woundMortals := powDist(critWoundMortals, c)
+		for w := 0; w <= n-c; w++ {
+			pWound := float64(nCr(n, c)*nCr(n-c, w)) *
+				powFloat(rolls.crit, c) * powFloat(rolls.nonCrit, w) * powFloat(rolls.fail, n-c-w)
+			if pWound == 0 {
+				continue
+			}
+
+			streams := woundStreams{}
+			streams.Saveable[class] = w
+			switch {
+			case a.LegacyDevastatingWounds:
+			case a.DevastatingWounds:
+				streams.Devastating = c
+			default:
+				streams.Saveable[critClass] += c
+			}
+
+			for m, pM := range woundMortals {
+				streams.Mortal = m
+				dist[streams] += pWound * pM
+			}
+		}
+	}
+	return dist
+}
+
+// volleyWoundStreamsWithRerolls returns the stream PMF of a whole volley,
+// applying the single hit and wound rerolls of req. Failed hit and wound
+// rolls add nothing to the streams, so each reroll is exact: the pool is
+// kept + fresh ⊛ (all − kept), where kept is the mass in which no roll can
+// use the reroll and fresh the streams of the rerolled die. The wound
+// reroll is applied last, over pools that already used the hit reroll.
+func volleyWoundStreamsWithRerolls(req CombatSimulationRequest, woundOutcomeDist map[WoundOutcome]float64,
+	attackCountDist map[int]float64) map[woundStreams]float64 {
+	probNonCritWound, probCritWound := splitWoundOutcomes(woundOutcomeDist, true)
+	critical := criticalHitWoundRolls(req)
+	hits := streamHitResults(req, req.Settings.HitReroll)
+	singleHit := computeSingleHitReroll(req)
+
+	// hitPool returns the volley's streams when each normal wound roll
+	// fails with probability probFail.
+	hitPool := func(probFail float64) map[woundStreams]float64 {
+		normal := woundRolls{nonCrit: probNonCritWound, crit: probCritWound, fail: probFail}
+		all := volleyWoundStreams(attackWoundStreams(req, hits, normal, critical), attackCountDist)
+		if singleHit == nil {
+			return all
+		}
+		kept := make(map[hitResult]float64, len(hits))
+		for hit, p := range hits {
+			kept[hit] = p
+		}
+		kept[hitResult{}] -= singleHit.eligible
+		return pooledSingleReroll(
+			volleyWoundStreams(attackWoundStreams(req, kept, normal, critical), attackCountDist),
+			all,
+			attackWoundStreams(req, streamHitResults(req, RerollNone), normal, critical),
+		)
+	}
+
+	probFail := 1.0 - probNonCritWound - probCritWound
+	all := hitPool(probFail)
+	singleWound := computeSingleWoundReroll(req, true)
+	if singleWound == nil {
+		return all
+	}
+	rerolledWound := map[hitResult]float64{{outcome: HitOutcome{NormalHits: 1}}: 1.0}
+	return pooledSingleReroll(
+		hitPool(probFail-singleWound.eligible),
+		all,
+		attackWoundStreams(req, rerolledWound, woundRolls{
+			nonCrit: singleWound.probNormal,
+			crit:    singleWound.probDev,
+			fail:    1.0 - singleWound.probNormal - singleWound.probDev,
+		}, critical),
+	)
+}
+
+// pooledSingleReroll returns kept + fresh ⊛ (all − kept).
+func pooledSingleReroll(kept, all, fresh map[woundStreams]float64) map[woundStreams]float64 {
+	rerolled := make(map[woundStreams]float64, len(all))
+	for s, p := range all {
+		rerolled[s] = p - kept[s]
+	}
+	result := convolveWoundStreams(rerolled, fresh)
+	for s, p := range kept {
+		result[s] += p
+	}
+	for s, p := range result {
+		if p < fineNegligibleProbability {
+			delete(result, s)
+		}
+	}
+	return result
+}
+
+// convolveWoundStreams returns the stream PMF of the sum of two
+// independent stream counts.
+func convolveWoundStreams(left, right map[woundStreams]float64) map[woundStreams]float64 {
+	result := make(map[woundStreams]float64, len(left))
+	for l, pLeft := range left {
+		for r, pRight := range right {
+			p := pLeft * pRight
+			if math.Abs(p) < fineNegligibleProbability {
+				continue
+			}
+			sum := woundStreams{Devastating: l.Devastating + r.Devastating, Mortal: l.Mortal + r.Mortal}
+			for c := range sum.Saveable {
+				sum.Saveable[c] = l.Saveable[c] + r.Saveable[c]
+			}
+			result[sum] += p
+		}
+	}
+	return result
+}
+
+// volleyWoundStreams sums the single-attack stream PMF over the number of
+// attacks: Σ_N P(N) · single^{*N}.
+func volleyWoundStreams(single map[woundStreams]float64, attackCountDist map[int]float64) map[woundStreams]float64 {
+	maxAttacks := 0
+	for n := range attackCountDist {
+		maxAttacks = max(maxAttacks, n)
+	}
+
+	result := make(map[woundStreams]float64)
+	current := map[woundStreams]float64{{}: 1.0}
+	for n := 0; n <= maxAttacks; n++ {
+		if n > 0 {
+			current = convolveWoundStreams(current, single)
+		}
+		if pN := attackCountDist[n]; pN > 0 {
+			for s, p := range current {
+				result[s] += pN * p
+			}
+		}
+	}
+	return result
+}
+
+// jointWoundStreams converts the dense (normal, devastating) wound matrix
+// into the stream PMF consumed by computeDamageAllocation.
+func jointWoundStreams(jointWoundDist NormalDevastatingWoundMatrix, maxHits int) map[woundStreams]float64 {
+	dist := make(map[woundStreams]float64)
+	for nw := 0; nw <= maxHits; nw++ {
+		for dw := 0; dw <= maxHits; dw++ {
+			if p := jointWoundDist[nw][dw]; p >= coarseNegligibleProbability {
+				dist[woundStreams{Saveable: [numWoundClasses]int{nw}, Devastating: dw}] = p
+			}
+		}
+	}
+	return dist
+}
+
+// streamWoundDists returns the PMFs of the wounds and of the unsaved
+// wounds of a stream PMF, for volleys whose wounds are not read off the
+// dense wound matrix. Mortal wounds are not counted.
+func streamWoundDists(dist map[woundStreams]float64, saves classSaves) (wounds, unsaved map[int]float64) {
+	wounds = make(map[int]float64)
+	unsaved = make(map[int]float64)
+	totals := make(map[[numWoundClasses]int][]float64)
+	for _, s := range sortedWoundStreams(dist) {
+		p := dist[s]
+		wounds[s.saveable()+s.Devastating] += p
+		total, ok := totals[s.Saveable]
+		if !ok {
+			total = saves.unsavedTotal(s.Saveable)
+			totals[s.Saveable] = total
+		}
+		for n, pU := range total {
+			unsaved[n+s.Devastating] += p * pU
+		}
+	}
+	return pruneDist(wounds), pruneDist(unsaved)
+}
+
+// mortalWoundMarginal returns the PMF of the number of mortal wounds.
+func mortalWoundMarginal(dist map[woundStreams]float64) map[int]float64 {
+	res := make(map[int]float64)
+	for s, p := range dist {
+		res[s.Mortal] += p
+	}
+	return pruneDist(res)
+}
+
+// pruneDist drops the outcomes of a PMF whose probability is negligible.
+func pruneDist(dist map[int]float64) map[int]float64 {
+	for k, p := range dist {
+		if p <= negligibleProbability {
+			delete(dist, k)
+		}
+	}
+	return dist
+}
+
+// sortedWoundStreams returns the keys of dist in a fixed order so that
+// floating-point accumulation is reproducible between runs.
+func sortedWoundStreams(dist map[woundStreams]float64) []woundStreams {
+	keys := make([]woundStreams, 0, len(dist))
+	for s := range dist {
+		keys = append(keys, s)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		for c := range keys[i].Saveable {
+			if keys[i].Saveable[c] != keys[j].Saveable[c] {
+				return keys[i].Saveable[c] < keys[j].Saveable[c]
+			}
+		}
+		if keys[i].Devastating != keys[j].Devastating {
+			return keys[i].Devastating < keys[j].Devastating
+		}
+		return keys[i].Mortal < keys[j].Mortal
+	})
+	return keys
+}
+
+// convolveDist returns the PMF of the sum of two independent variables.
+func convolveDist(a, b map[int]float64) map[int]float64 {
+	res := make(map[int]float64, len(a)*len(b))
+	for x, pX := range a {
+		for y, pY := range b {
+			res[x+y] += pX * pY
+		}
+	}
+	return res
+}
+
+// powDist returns the PMF of the sum of n independent copies of dist.
+func powDist(dist map[int]float64, n int) map[int]float64 {
+	res := map[int]float64{0: 1.0}
+	for i := 0; i < n; i++ {
+		res = convolveDist(res, dist)
+	}
+	return res
+}
+
+// powFloat is math.Pow for small non-negative integer exponents, with
+// 0^0 = 1.
+func powFloat(p float64, n int) float64 {
+	res := 1.0
+	for i := 0; i < n; i++ {
+		res *= p
+	}
+	return res
+}
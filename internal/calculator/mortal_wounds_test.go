@@ -0,0 +1,246 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateDamageCore_MortalWounds(t *testing.T) {
+	// Torrent, S4 vs T4 (wounds on 4+, Critical Wound on a 6) and an
+	// impossible Save 7+ leave three branches per attack: fail (1/2),
+	// non-critical wound (1/3) and Critical Wound (1/6).
+	base := func() CombatSimulationRequest {
+		return CombatSimulationRequest{
+			Attacker: AttackerProfile{
+				Count:    1,
+				Attacks:  DiceRoll{Modifier: 1},
+				Torrent:  true,
+				Strength: 4,
+				Damage:   DiceRoll{Modifier: 1},
+			},
+			Target: TargetProfile{
+				Count:          intPtr(5),
+				Toughness:      4,
+				Save:           7,
+				WoundsPerModel: 1,
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		modify            func(*CombatSimulationRequest)
+		expectedMortal    map[int]float64
+		expectedDestroyed map[int]float64
+		expectedDamage    map[int]float64
+	}{
+		{
+			name: "No mortal wound source",
+			modify: func(req *CombatSimulationRequest) {
+			},
+			expectedMortal:    map[int]float64{0: 1.0},
+			expectedDestroyed: map[int]float64{0: 1.0 / 2.0, 1: 1.0 / 2.0},
+			expectedDamage:    map[int]float64{0: 1.0 / 2.0, 1: 1.0 / 2.0},
+		},
+		{
+			// The Critical Wound still deals its own damage, then its 3
+			// mortal wounds spill over into 3 more single-wound models.
+			name: "3 mortal wounds per Critical Wound spill over",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.MortalWounds = DiceRoll{Modifier: 3}
+				req.Attacker.MortalWoundsOn = MortalWoundsOnCriticalWound
+			},
+			expectedMortal:    map[int]float64{0: 5.0 / 6.0, 3: 1.0 / 6.0},
+			expectedDestroyed: map[int]float64{0: 1.0 / 2.0, 1: 1.0 / 3.0, 4: 1.0 / 6.0},
+			expectedDamage:    map[int]float64{0: 1.0 / 2.0, 1: 1.0 / 3.0, 4: 1.0 / 6.0},
+		},
+		{
+			// Damage 4 against 2-wound models: a normal wound loses its
+			// excess damage, while the old wording turns the Critical Wound
+			// into 4 mortal wounds that destroy two models.
+			name: "Legacy Devastating Wounds spill over",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.Damage = DiceRoll{Modifier: 4}
+				req.Attacker.LegacyDevastatingWounds = true
+				req.Target.WoundsPerModel = 2
+				req.Target.Count = intPtr(3)
+			},
+			expectedMortal:    map[int]float64{0: 5.0 / 6.0, 4: 1.0 / 6.0},
+			expectedDestroyed: map[int]float64{0: 1.0 / 2.0, 1: 1.0 / 3.0, 2: 1.0 / 6.0},
+			expectedDamage:    map[int]float64{0: 1.0 / 2.0, 4: 1.0 / 2.0},
+		},
+		{
+			// The mortal-only FNP ignores half of the mortal wounds but
+			// never the Critical Wound's own damage.
+			name: "Mortal-only Feel No Pain",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.MortalWounds = DiceRoll{Modifier: 1}
+				req.Attacker.MortalWoundsOn = MortalWoundsOnCriticalWound
+				req.Target.MortalFeelNoPain = intPtr(4)
+			},
+			expectedMortal:    map[int]float64{0: 5.0 / 6.0, 1: 1.0 / 6.0},
+			expectedDestroyed: map[int]float64{0: 1.0 / 2.0, 1: 1.0/3.0 + 1.0/12.0, 2: 1.0 / 12.0},
+			expectedDamage:    map[int]float64{0: 1.0 / 2.0, 1: 1.0/3.0 + 1.0/12.0, 2: 1.0 / 12.0},
+		},
+		{
+			// BS 4+: a Critical Hit (1/6) inflicts D3 mortal wounds
+			// regardless of whether the attack goes on to wound.
+			name: "D3 mortal wounds per Critical Hit",
+			modify: func(req *CombatSimulationRequest) {
+				req.Attacker.Torrent = false
+				req.Attacker.BS = 4
+				req.Attacker.MortalWounds = DiceRoll{Count: 1, Sides: 3}
+				req.Attacker.MortalWoundsOn = MortalWoundsOnCriticalHit
+			},
+			expectedMortal: map[int]float64{
+				0: 5.0 / 6.0,
+				1: 1.0 / 18.0,
+				2: 1.0 / 18.0,
+				3: 1.0 / 18.0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			tt.modify(&req)
+
+			calc := &DamageCalculatorImpl{}
+			resp, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			verifyDist(t, "MortalWoundDist", resp.MortalWoundDist, tt.expectedMortal)
+			if tt.expectedDestroyed != nil {
+				verifyDist(t, "DestroyedDist", resp.DestroyedDist, tt.expectedDestroyed)
+			}
+			if tt.expectedDamage != nil {
+				verifyDist(t, "DamageDist", resp.DamageDist, tt.expectedDamage)
+			}
+		})
+	}
+}
+
+func TestVolleyWoundStreams_MatchesWoundMatrix(t *testing.T) {
+	// Without a mortal wound source, the per-attack stream PMF must agree
+	// with the dense (normal, devastating) matrix pipeline.
+	req := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:             3,
+			Attacks:           DiceRoll{Count: 1, Sides: 3},
+			BS:                3,
+			Strength:          4,
+			Damage:            DiceRoll{Modifier: 1},
+			LethalHits:        true,
+			SustainedHits:     1,
+			DevastatingWounds: true,
+		},
+		Target: TargetProfile{Count: intPtr(10), Toughness: 4, Save: 3, WoundsPerModel: 1},
+		Settings: SimulationSettings{
+			HitReroll:            RerollOnes,
+			CriticalHitThreshold: 5,
+		},
+	}
+	calc := &DamageCalculatorImpl{}
+	calc.Hydrate(&req)
+
+	attackCountDist := CalculateAttackDistribution(req.Attacker.Attacks, DiceOptions{}, req.Attacker.Count, false, 0, 0, false, false)
+	hitOutcomeDist := computeHitOutcomeDist(req)
+	probNormalWound, probDevWound := CalculateWoundProbability(4, 4, RerollNone, 0, true, 6, nil, nil, 0)
+	bounds := computeHitBounds(attackCountDist, hitOutcomeDist)
+	autoWoundNormalHitDist := computeAutoWoundNormalHitDist(hitOutcomeDist, attackCountDist, bounds, nil)
+	want := jointWoundStreams(computeJointWoundDist(autoWoundNormalHitDist, bounds, probNormalWound, probDevWound, nil), bounds.maxHits)
+
+	got := volleyWoundStreams(singleAttackWoundStreams(req, probNormalWound, probDevWound), attackCountDist)
+
+	total := 0.0
+	for s, p := range got {
+		total += p
+		if math.Abs(p-want[s]) > epsilonCore {
+			t.Errorf("%+v: got %v, want %v", s, p, want[s])
+		}
+	}
+	for s, p := range want {
+		if _, ok := got[s]; !ok && p > epsilonCore {
+			t.Errorf("%+v: missing from stream PMF (want %v)", s, p)
+		}
+	}
+	if math.Abs(total-1.0) > epsilonCore {
+		t.Errorf("stream PMF sums to %v, want 1", total)
+	}
+}
+
+func TestVolleyWoundStreamsWithRerolls_MatchesJointWoundDist(t *testing.T) {
+	// The stream PMF applies the single rerolls to pooled attacks, the
+	// dense matrices to pooled hit and wound counts; without a mortal
+	// wound source both must agree.
+	req := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:             3,
+			Attacks:           DiceRoll{Count: 1, Sides: 3},
+			BS:                3,
+			Strength:          4,
+			Damage:            DiceRoll{Modifier: 1},
+			LethalHits:        true,
+			SustainedHits:     1,
+			DevastatingWounds: true,
+		},
+		Target: TargetProfile{Count: intPtr(10), Toughness: 5, Save: 3, WoundsPerModel: 1},
+		Settings: SimulationSettings{
+			HitReroll:            RerollOnes,
+			CriticalHitThreshold: 5,
+			SingleReroll:         SingleRerolls{Hit: true, Wound: true},
+		},
+	}
+	calc := &DamageCalculatorImpl{}
+	calc.Hydrate(&req)
+
+	attackCountDist := CalculateAttackDistribution(req.Attacker.Attacks, DiceOptions{}, req.Attacker.Count, false, 0, 0, false, false)
+	hitOutcomeDist := computeHitOutcomeDist(req)
+	woundOutcomeDist := computeWoundOutcomeDist(req)
+	probNormalWound, probDevWound := splitWoundOutcomes(woundOutcomeDist, true)
+	bounds := computeHitBounds(attackCountDist, hitOutcomeDist)
+	autoWoundNormalHitDist := computeAutoWoundNormalHitDist(hitOutcomeDist, attackCountDist, bounds, computeSingleHitReroll(req))
+	want := jointWoundStreams(computeJointWoundDist(autoWoundNormalHitDist, bounds, probNormalWound, probDevWound,
+		computeSingleWoundReroll(req, true)), bounds.maxHits)
+
+	got := volleyWoundStreamsWithRerolls(req, woundOutcomeDist, attackCountDist)
+
+	total := 0.0
+	for s, p := range got {
+		total += p
+		if math.Abs(p-want[s]) > epsilonCore {
+			t.Errorf("%+v: got %v, want %v", s, p, want[s])
+		}
+	}
+	for s, p := range want {
+		if _, ok := got[s]; !ok && p > epsilonCore {
+			t.Errorf("%+v: missing from stream PMF (want %v)", s, p)
+		}
+	}
+	if math.Abs(total-1.0) > epsilonCore {
+		t.Errorf("stream PMF sums to %v, want 1", total)
+	}
+}
@@ -59,11 +59,7 @@ func CalculateFailedSaveProbability(ap int, save int, invulnerable *int, saveMod
 
 	const oneSixth = 1.0 / 6.0
 
-	bocModifier := _getBenefitOfCoverModifier(save, ap, hasCover)
-	armorSaveTarget := modifiedArmorSaveTarget(save, ap, saveModifier, bocModifier)
-	finalTarget := betterSaveTarget(armorSaveTarget, invulnerable)
-
-	finalTarget, autoFail := clampSaveTarget(finalTarget)
+	finalTarget, autoFail := saveRollTarget(ap, save, invulnerable, saveModifier, hasCover)
 	if autoFail {
 		return 1.0
 	}
@@ -75,14 +71,48 @@ func CalculateFailedSaveProbability(ap int, save int, invulnerable *int, saveMod
 	case RerollOnes:
 		// Only a natural 1 can be rerolled.
 		failChance = applyRerollReduction(failChance, passChance, oneSixth)
-	case RerollFail:
-		// Every failed roll can be rerolled.
+	case RerollFail, RerollNonCritical, RerollOptimal:
+		// Every failed roll can be rerolled. Saves have no critical
+		// results, so fishing for them is the same as rerolling failures.
 		failChance = applyRerollReduction(failChance, passChance, failChance)
 	}
 
 	return math.Max(0.0, failChance)
 }
 
+// singleSaveReroll returns, for a single save reroll, the chance that a
+// saving throw fails with no other reroll to use (eligible) and the chance
+// that the rerolled save fails again (freshFailed). An auto-failed save
+// cannot be rerolled.
+func singleSaveReroll(ap int, save int, invulnerable *int, saveModifier int,
+	hasCover bool, saveReroll RerollType) (eligible, freshFailed float64) {
+
+	const oneSixth = 1.0 / 6.0
+
+	finalTarget, autoFail := saveRollTarget(ap, save, invulnerable, saveModifier, hasCover)
+	if autoFail {
+		return 0, 1.0
+	}
+	failChance := 1.0 - chanceOfRollingAtLeast(float64(finalTarget))
+
+	switch saveReroll {
+	case RerollNone:
+		eligible = failChance
+	case RerollOnes:
+		// Failed rolls other than a natural 1.
+		eligible = math.Max(0.0, failChance-oneSixth)
+	}
+	return eligible, failChance
+}
+
+// saveRollTarget returns the roll a saving throw needs, after AP, the
+// save modifier, the Benefit of Cover and the invulnerable save.
+func saveRollTarget(ap int, save int, invulnerable *int, saveModifier int, hasCover bool) (target int, autoFail bool) {
+	bocModifier := _getBenefitOfCoverModifier(save, ap, hasCover)
+	armorSaveTarget := modifiedArmorSaveTarget(save, ap, saveModifier, bocModifier)
+	return clampSaveTarget(betterSaveTarget(armorSaveTarget, invulnerable))
+}
+
 // modifiedArmorSaveTarget applies the general save modifier and the Benefit
 // of Cover bonus to the defender's armor save.
 func modifiedArmorSaveTarget(save, ap, saveModifier, bocModifier int) int {
@@ -0,0 +1,204 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "fmt"
+
+// CalculateSequence resolves the activations of req in order against its
+// target. The target is hydrated once, against every activation's
+// profiles, so all of them share one wound layout; each activation then
+// starts from the wound states the previous ones left behind, exactly as
+// the profiles of a single CalculateDamageCore request do.
+//
+// The models an activation destroys depend on the state it starts from,
+// so its volleys are allocated from each reachable state in turn. Once
+// the target is wiped for certain, the remaining activations are skipped.
+// A target with a WoundsRemainingDist starts from each of its states.
+// Single rerolls are rejected: their budget is the phase's, not an
+// activation's. The sequence is checked by SequenceValidator, or
+// DefaultSequenceComplexityValidator.
+func (d *DamageCalculatorImpl) CalculateSequence(req SequenceRequest) (SequenceResult, error) {
+	if len(req.Activations) == 0 {
+		return SequenceResult{}, fmt.Errorf("a sequence needs at least one activation")
+	}
+
+	var profiles []AttackerProfile
+	for _, a := range req.Activations {
+		profiles = append(profiles, a.Attacker)
+		profiles = append(profiles, a.Weapons...)
+	}
+	whole := CombatSimulationRequest{Attacker: profiles[0], Weapons: profiles[1:], Target: req.Target}
+	if whole.Target.Count == nil && len(whole.Target.Groups) == 0 {
+		// Every activation resolves its attacks with its own settings.
+		count := 0
+		for _, a := range req.Activations {
+			count += maxVolleyAttacks(append([]AttackerProfile{a.Attacker}, a.Weapons...), a.Settings)
+		}
+		count = min(count, maxResolvedTargetCount)
+		whole.Target.Count = &count
+	}
+	d.Hydrate(&whole)
+
+	hydrated := SequenceRequest{Target: whole.Target}
+	for _, a := range req.Activations {
+		if a.Settings.SingleReroll != (SingleRerolls{}) {
+			return SequenceResult{}, fmt.Errorf("single rerolls are not supported in a sequence")
+		}
+		step := CombatSimulationRequest{Attacker: a.Attacker, Weapons: a.Weapons, Target: whole.Target, Settings: a.Settings}
+		d.Hydrate(&step)
+		hydrated.Activations = append(hydrated.Activations, Activation{Attacker: step.Attacker, Weapons: step.Weapons, Settings: step.Settings})
+	}
+	if err := d.validateSequence(&hydrated); err != nil {
+		return SequenceResult{}, err
+	}
+
+	layout, err := newTargetLayout(whole.Target, usesPrecision(profiles))
+	if err != nil {
+		return SequenceResult{}, err
+	}
+
+	aliveIn := make([]int, layout.totalStates())
+	for s := range aliveIn {
+		aliveIn[s] = layout.aliveCount(s)
+	}
+
+	// The models destroyed since the first activation are counted from the
+	// state the target started in, so with a WoundsRemainingDist the wound
+	// states are tracked apart for each start state.
+	starts := map[int]float64{layout.start: 1}
+	if whole.Target.WoundsRemainingDist != nil {
+		if starts, err = layout.startDist(whole.Target.WoundsRemainingDist); err != nil {
+			return SequenceResult{}, err
+		}
+	}
+	byStart := make(map[int][]float64, len(starts))
+	states := make([]float64, layout.totalStates())
+	for start, p := range starts {
+		byStart[start] = make([]float64, len(states))
+		byStart[start][start] = p
+		states[start] += p
+	}
+
+	var result SequenceResult
+	for _, a := range hydrated.Activations {
+		stepReq := CombatSimulationRequest{
+			Attacker: a.Attacker,
+			Weapons:  a.Weapons,
+			Target:   whole.Target,
+			Settings: a.Settings,
+		}
+		// Blast counts the models left when the activation attacks, so a
+		// Blast activation resolves its volleys apart for every number of
+		// models the earlier activations can leave.
+		blast := usesBlast(stepReq.profiles())
+		byAlive := map[int][]int{}
+		for s, prob := range states {
+			if prob < negligibleProbability {
+				continue
+			}
+			alive := 0
+			if blast {
+				alive = aliveIn[s]
+			}
+			byAlive[alive] = append(byAlive[alive], s)
+		}
+
+		after := make(map[int][]float64)
+		for _, group := range byAlive {
+			from := layout
+			if blast {
+				from = layout.withStart(group[0])
+			}
+			groupStates := make([]float64, len(states))
+			for _, s := range group {
+				groupStates[s] = states[s]
+			}
+			volleys, _, err := d.prepareVolleys(stepReq, from, groupStates)
+			if err != nil {
+				return SequenceResult{}, err
+			}
+			for _, s := range group {
+				to := make([]float64, len(states))
+				to[s] = 1
+				for _, v := range volleys {
+					to, _ = v.allocate(to)
+				}
+				after[s] = to
+			}
+		}
+
+		states = make([]float64, len(states))
+		destroyed := make(map[int]float64)
+		cumulative := make(map[int]float64)
+		for start, startStates := range byStart {
+			next := make([]float64, len(states))
+			for s, prob := range startStates {
+				for to, p := range after[s] {
+					if p == 0 {
+						continue
+					}
+					next[to] += prob * p
+					destroyed[aliveIn[s]-aliveIn[to]] += prob * p
+					cumulative[aliveIn[start]-aliveIn[to]] += prob * p
+				}
+			}
+			byStart[start] = next
+			for to, p := range next {
+				states[to] += p
+			}
+		}
+
+		result.Steps = append(result.Steps, SequenceStep{
+			DestroyedDist:           pruneDist(destroyed),
+			CumulativeDestroyedDist: pruneDist(cumulative),
+			WipeProb:                states[0],
+			RemainingWoundsDist:     vectorToMap(layout.remainingWounds(states)),
+		})
+		if survivingMass(states) < negligibleProbability {
+			break
+		}
+	}
+
+	last := result.Steps[len(result.Steps)-1]
+	result.DestroyedDist = last.CumulativeDestroyedDist
+	result.WipeProb = last.WipeProb
+	return result, nil
+}
+
+// validateSequence runs the calculator's SequenceValidator, or
+// DefaultSequenceComplexityValidator if it has none, over a hydrated
+// sequence.
+func (d *DamageCalculatorImpl) validateSequence(req *SequenceRequest) error {
+	if d.SequenceValidator == nil {
+		return DefaultSequenceComplexityValidator(req)
+	}
+	return d.SequenceValidator(req)
+}
+
+// survivingMass is the probability that any model of the target is left
+// alive: the mass of every state but the wiped one.
+func survivingMass(states []float64) float64 {
+	mass := 0.0
+	for _, prob := range states[1:] {
+		mass += prob
+	}
+	return mass
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "testing"
+
+func sequenceOf(req CombatSimulationRequest, attackers ...AttackerProfile) SequenceRequest {
+	seq := SequenceRequest{Target: req.Target}
+	for _, a := range attackers {
+		seq.Activations = append(seq.Activations, Activation{Attacker: a, Settings: req.Settings})
+	}
+	return seq
+}
+
+func TestCalculateSequence_MatchesSingleRequest(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	second := req.Attacker
+	second.Strength = 8
+	second.Damage = DiceRoll{Modifier: 2}
+
+	// One activation is a plain CalculateDamageCore request.
+	single, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := calc.CalculateSequence(sequenceOf(req, req.Attacker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyDist(t, "one activation", seq.DestroyedDist, single.DestroyedDist)
+	verifyDist(t, "one activation step", seq.Steps[0].DestroyedDist, single.DestroyedDist)
+	verifyValue(t, "one activation wipe", seq.WipeProb, single.WipeProb)
+	verifyDist(t, "one activation wounds", seq.Steps[0].RemainingWoundsDist, single.RemainingWoundsDist)
+
+	// Two activations destroy what the two profiles of one unit would.
+	req.Weapons = []AttackerProfile{second}
+	both, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err = calc.CalculateSequence(sequenceOf(req, req.Attacker, second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seq.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(seq.Steps))
+	}
+	verifyDist(t, "two activations", seq.DestroyedDist, both.DestroyedDist)
+	verifyDist(t, "first step cumulative", seq.Steps[0].CumulativeDestroyedDist, single.DestroyedDist)
+
+	// What the second activation destroys is what it adds to the total.
+	verifyValue(t, "second step mean",
+		summarizeDist(seq.Steps[1].DestroyedDist).Mean,
+		summarizeDist(seq.DestroyedDist).Mean-summarizeDist(single.DestroyedDist).Mean)
+	total := 0.0
+	for _, p := range seq.Steps[1].DestroyedDist {
+		total += p
+	}
+	verifyValue(t, "second step mass", total, 1)
+}
+
+func TestCalculateSequence_StopsOnceWiped(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Attacker.Count = 100
+	req.Target.Count = intPtr(1)
+	req.Target.WoundsPerModel = 1
+
+	seq, err := calc.CalculateSequence(sequenceOf(req, req.Attacker, req.Attacker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seq.Steps) != 1 {
+		t.Fatalf("expected the sequence to stop after 1 step, got %d", len(seq.Steps))
+	}
+	verifyValue(t, "wipe", seq.WipeProb, 1)
+}
+
+func TestCalculateSequence_StartState(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Target.WoundsRemaining = intPtr(5)
+
+	single, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := calc.CalculateSequence(sequenceOf(req, req.Attacker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyDist(t, "wounded target", seq.DestroyedDist, single.DestroyedDist)
+}
+
+func TestCalculateSequence_StartDist(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Target.WoundsRemainingDist = map[int]float64{0: 0.25, 3: 0.25, 5: 0.5}
+
+	single, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := calc.CalculateSequence(sequenceOf(req, req.Attacker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyDist(t, "one activation", seq.DestroyedDist, single.DestroyedDist)
+	verifyValue(t, "one activation wipe", seq.WipeProb, single.WipeProb)
+
+	// Two activations destroy, from each start state, what they destroy
+	// from it alone.
+	twice := sequenceOf(req, req.Attacker, req.Attacker)
+	seq, err = calc.CalculateSequence(twice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]float64{}
+	wantWipe := 0.0
+	for left, weight := range req.Target.WoundsRemainingDist {
+		from := twice
+		from.Target.WoundsRemainingDist = nil
+		from.Target.WoundsRemaining = intPtr(left)
+		if left == 0 {
+			want[0] += weight
+			wantWipe += weight
+			continue
+		}
+		alone, err := calc.CalculateSequence(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, p := range alone.DestroyedDist {
+			want[k] += weight * p
+		}
+		wantWipe += weight * alone.WipeProb
+	}
+	verifyDist(t, "two activations", seq.DestroyedDist, want)
+	verifyValue(t, "two activations wipe", seq.WipeProb, wantWipe)
+}
+
+func TestCalculateSequence_BlastAfterLosses(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Target.WoundsPerModel = 1
+	blast := req.Attacker
+	blast.Count = 1
+	blast.Attacks = DiceRoll{Count: 1, Sides: 3}
+	blast.Blast = true
+
+	seq, err := calc.CalculateSequence(sequenceOf(req, req.Attacker, blast))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Blast activation counts the models the first one leaves behind.
+	after := req
+	after.Attacker = blast
+	after.Target.WoundsRemainingDist = seq.Steps[0].RemainingWoundsDist
+	want, err := calc.CalculateDamageCore(after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyDist(t, "blast after losses", seq.Steps[1].DestroyedDist, want.DestroyedDist)
+}
+
+func TestCalculateSequence_ResolvedTargetCount(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Target.Count = nil
+	req.Target.WoundsPerModel = 1
+	req.Attacker.Count = 1
+	req.Attacker.Attacks = DiceRoll{Modifier: 1}
+	req.Attacker.RapidFireX = 1
+	req.Settings.HalfRange = true
+
+	// The target is sized for the attacks the activation makes at half
+	// range.
+	single, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := calc.CalculateSequence(sequenceOf(req, req.Attacker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyDist(t, "half range", seq.DestroyedDist, single.DestroyedDist)
+}
+
+func TestCalculateSequence_SingleReroll(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Settings.SingleReroll.Hit = true
+	if _, err := calc.CalculateSequence(sequenceOf(req, req.Attacker, req.Attacker)); err == nil {
+		t.Error("expected an error for a single reroll in a sequence")
+	}
+}
+
+func TestDefaultSequenceComplexityValidator(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	req := generateBaseRequest()
+	req.Attacker.Count = 10
+	req.Attacker.Attacks = DiceRoll{Modifier: 2}
+	req.Target.Count = intPtr(20)
+	req.Target.WoundsPerModel = 6
+	calc.Hydrate(&req)
+	if err := DefaultComplexityValidator(&req); err != nil {
+		t.Fatalf("a single activation should pass: %v", err)
+	}
+
+	// Every activation after the first allocates from each of the
+	// target's 121 states.
+	short := sequenceOf(req, req.Attacker, req.Attacker)
+	if err := DefaultSequenceComplexityValidator(&short); err != nil {
+		t.Errorf("two activations should pass: %v", err)
+	}
+	long := sequenceOf(req, req.Attacker, req.Attacker, req.Attacker, req.Attacker, req.Attacker,
+		req.Attacker, req.Attacker, req.Attacker, req.Attacker, req.Attacker)
+	if err := DefaultSequenceComplexityValidator(&long); err == nil {
+		t.Error("ten activations should be rejected")
+	}
+	if _, err := calc.CalculateSequence(long); err == nil {
+		t.Error("CalculateSequence should reject ten activations")
+	}
+}
+
+func TestCalculateSequence_NoActivations(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	if _, err := calc.CalculateSequence(SequenceRequest{Target: generateBaseRequest().Target}); err == nil {
+		t.Error("expected an error for an empty sequence")
+	}
+}
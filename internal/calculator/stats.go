@@ -0,0 +1,133 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import (
+	"math"
+	"sort"
+)
+
+// DistStats summarizes a distribution over non-negative counts.
+type DistStats struct {
+	Mean     float64
+	Variance float64
+	StdDev   float64
+	// Median, P10 and P90 are the smallest values whose cumulative
+	// probability reaches 50%, 10% and 90%.
+	Median int
+	P10    int
+	P90    int
+	// Mode is the most likely value, the smallest one on a tie.
+	Mode int
+	// AtLeast maps each value k from 0 to the largest possible value to
+	// P(X >= k).
+	AtLeast map[int]float64
+}
+
+// DistributionStats holds the DistStats of each distribution of a
+// SimulationResult.
+type DistributionStats struct {
+	Hits      DistStats
+	Wounds    DistStats
+	Pens      DistStats
+	Damage    DistStats
+	Destroyed DistStats
+}
+
+// summarizeDist computes the DistStats of a PMF. Probabilities are not
+// renormalized: mass pruned from the tail is simply missing from the
+// upper percentiles' cumulative sums.
+func summarizeDist(dist map[int]float64) DistStats {
+	stats := DistStats{AtLeast: make(map[int]float64)}
+	if len(dist) == 0 {
+		return stats
+	}
+
+	values := make([]int, 0, len(dist))
+	for v := range dist {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+
+	bestP := -1.0
+	for _, v := range values {
+		p := dist[v]
+		stats.Mean += float64(v) * p
+		if p > bestP {
+			stats.Mode, bestP = v, p
+		}
+	}
+	for _, v := range values {
+		d := float64(v) - stats.Mean
+		stats.Variance += d * d * dist[v]
+	}
+	stats.StdDev = math.Sqrt(stats.Variance)
+
+	stats.P10 = distQuantile(values, dist, 0.1)
+	stats.Median = distQuantile(values, dist, 0.5)
+	stats.P90 = distQuantile(values, dist, 0.9)
+
+	tail := 0.0
+	next := len(values) - 1
+	for k := values[len(values)-1]; k >= 0; k-- {
+		for next >= 0 && values[next] >= k {
+			tail += dist[values[next]]
+			next--
+		}
+		stats.AtLeast[k] = tail
+	}
+	return stats
+}
+
+// distQuantile returns the smallest of the sorted values whose cumulative
+// probability reaches q, or the largest value if none does.
+func distQuantile(values []int, dist map[int]float64, q float64) int {
+	// A small tolerance keeps rounding from skipping an exact quantile.
+	const tolerance = 1e-12
+	cdf := 0.0
+	for _, v := range values {
+		cdf += dist[v]
+		if cdf >= q-tolerance {
+			return v
+		}
+	}
+	return values[len(values)-1]
+}
+
+// probAtLeast returns P(X >= k) for the PMF dist.
+func probAtLeast(dist map[int]float64, k int) float64 {
+	p := 0.0
+	for v, pV := range dist {
+		if v >= k {
+			p += pV
+		}
+	}
+	return p
+}
+
+// tailMass returns the probability missing from a pruned PMF.
+func tailMass(dist map[int]float64) float64 {
+	total := 0.0
+	for _, p := range dist {
+		total += p
+	}
+	return max(0, 1.0-total)
+}
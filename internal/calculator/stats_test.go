@@ -0,0 +1,124 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "testing"
+
+func TestSummarizeDist(t *testing.T) {
+	tests := []struct {
+		name string
+		dist map[int]float64
+		want DistStats
+	}{
+		{
+			name: "Empty",
+			dist: map[int]float64{},
+			want: DistStats{},
+		},
+		{
+			name: "Certain outcome",
+			dist: map[int]float64{3: 1.0},
+			want: DistStats{
+				Mean: 3, Median: 3, P10: 3, P90: 3, Mode: 3,
+				AtLeast: map[int]float64{0: 1, 1: 1, 2: 1, 3: 1},
+			},
+		},
+		{
+			name: "Two dice faces",
+			dist: map[int]float64{0: 0.25, 1: 0.5, 2: 0.25},
+			want: DistStats{
+				Mean: 1, Variance: 0.5, StdDev: 0.7071067811865476,
+				Median: 1, P10: 0, P90: 2, Mode: 1,
+				AtLeast: map[int]float64{0: 1, 1: 0.75, 2: 0.25},
+			},
+		},
+		{
+			name: "Ties take the smaller mode, gaps still count",
+			dist: map[int]float64{1: 0.4, 4: 0.4, 5: 0.2},
+			want: DistStats{
+				Mean: 3, Variance: 2.8, StdDev: 1.6733200530681511,
+				Median: 4, P10: 1, P90: 5, Mode: 1,
+				AtLeast: map[int]float64{0: 1, 1: 1, 2: 0.6, 3: 0.6, 4: 0.6, 5: 0.2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeDist(tt.dist)
+			verifyValue(t, "Mean", got.Mean, tt.want.Mean)
+			verifyValue(t, "Variance", got.Variance, tt.want.Variance)
+			verifyValue(t, "StdDev", got.StdDev, tt.want.StdDev)
+			if got.Median != tt.want.Median || got.P10 != tt.want.P10 || got.P90 != tt.want.P90 || got.Mode != tt.want.Mode {
+				t.Errorf("median/p10/p90/mode: got %d/%d/%d/%d, want %d/%d/%d/%d",
+					got.Median, got.P10, got.P90, got.Mode,
+					tt.want.Median, tt.want.P10, tt.want.P90, tt.want.Mode)
+			}
+			verifyDist(t, "AtLeast", got.AtLeast, tt.want.AtLeast)
+		})
+	}
+}
+
+func TestCalculateDamageCore_Stats(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+	res, err := calc.CalculateDamageCore(generateBaseRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyValue(t, "Stats.Hits.Mean", res.Stats.Hits.Mean, res.AverageHits)
+	verifyValue(t, "Stats.Destroyed.Mean", res.Stats.Destroyed.Mean, res.AverageDestroyed)
+	// 20 attacks hitting on 3+: a binomial with variance n·p·(1−p).
+	verifyValue(t, "Stats.Hits.Variance", res.Stats.Hits.Variance, 20.0*2.0/3.0*1.0/3.0)
+	verifyValue(t, "Stats.Hits.AtLeast[0]", res.Stats.Hits.AtLeast[0], 1.0)
+}
+
+func TestProbAtLeastAndTailMass(t *testing.T) {
+	dist := map[int]float64{0: 0.5, 2: 0.3, 5: 0.15}
+	verifyValue(t, "P(X >= 0)", probAtLeast(dist, 0), 0.95)
+	verifyValue(t, "P(X >= 1)", probAtLeast(dist, 1), 0.45)
+	verifyValue(t, "P(X >= 6)", probAtLeast(dist, 6), 0)
+	verifyValue(t, "tailMass", tailMass(dist), 0.05)
+	verifyValue(t, "tailMass of a full PMF", tailMass(map[int]float64{1: 1.0}), 0)
+}
+
+func TestCalculateDamageCore_Thresholds(t *testing.T) {
+	calc := &DamageCalculatorImpl{}
+
+	// One auto-hitting S4 AP-1 attack against a single T4 3+ model with one
+	// wound: it wounds on a 4+ and the save fails on 1-3.
+	req := generateBaseRequest()
+	req.Attacker.Count = 1
+	req.Attacker.Attacks = DiceRoll{Modifier: 1}
+	req.Attacker.Torrent = true
+	req.Target.Count = intPtr(1)
+	req.Target.WoundsPerModel = 1
+	req.Thresholds = ResultThresholds{Destroyed: []int{0, 1, 2}, Damage: []int{1}}
+
+	res, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyDist(t, "DestroyedAtLeast", res.DestroyedAtLeast, map[int]float64{0: 1.0, 1: 0.25, 2: 0})
+	verifyDist(t, "DamageAtLeast", res.DamageAtLeast, map[int]float64{1: 0.25})
+	verifyValue(t, "WipeProb", res.WipeProb, 0.25)
+	verifyValue(t, "DestroyedTailMass", res.DestroyedTailMass, 0)
+	verifyValue(t, "DamageTailMass", res.DamageTailMass, 0)
+}
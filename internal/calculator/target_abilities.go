@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+// applyTargetAbilities folds the target's defensive abilities into the
+// attack of a single profile:
+//
+//   - Stealth: a -1 to hit source.
+//   - MinusOneToWoundIfStronger: a -1 to wound source if the attack's
+//     Strength is greater than the target's Toughness.
+//   - APReduction: the attack's AP is worsened, to a minimum of 0.
+//
+// The modifiers are added as sources rather than applied, so it must run
+// before applyWeaponAbilities caps them. WoundRollsFailUpTo is read by the
+// wound roll itself (see CalculateSingleWoundDistribution).
+func applyTargetAbilities(req *CombatSimulationRequest) {
+	t := req.Target
+	s := &req.Settings
+
+	if t.Stealth {
+		s.HitModifiers = withModifier(s.HitModifiers, Modifier{Source: "Stealth", Value: -1})
+	}
+	if t.MinusOneToWoundIfStronger && req.Attacker.Strength > t.Toughness {
+		s.WoundModifiers = withModifier(s.WoundModifiers, Modifier{Source: "-1 to wound", Value: -1})
+	}
+	if t.APReduction > 0 {
+		req.Attacker.AP = max(0, req.Attacker.AP-t.APReduction)
+	}
+}
+
+// withModifier returns a copy of mods with m appended, so the settings
+// shared by every profile are never modified.
+func withModifier(mods []Modifier, m Modifier) []Modifier {
+	return append(append([]Modifier(nil), mods...), m)
+}
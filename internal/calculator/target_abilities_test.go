@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "testing"
+
+func TestApplyTargetAbilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		attacker     AttackerProfile
+		target       TargetProfile
+		wantHitMod   int
+		wantWoundMod int
+		wantAP       int
+	}{
+		{
+			name:       "Stealth: -1 to hit",
+			attacker:   AttackerProfile{Strength: 4, AP: 1},
+			target:     TargetProfile{Toughness: 4, Stealth: true},
+			wantHitMod: -1,
+			wantAP:     1,
+		},
+		{
+			name:         "-1 to wound against a stronger attack",
+			attacker:     AttackerProfile{Strength: 5},
+			target:       TargetProfile{Toughness: 4, MinusOneToWoundIfStronger: true},
+			wantWoundMod: -1,
+		},
+		{
+			name:     "-1 to wound does not apply at equal Strength",
+			attacker: AttackerProfile{Strength: 4},
+			target:   TargetProfile{Toughness: 4, MinusOneToWoundIfStronger: true},
+		},
+		{
+			name:     "AP is worsened to a minimum of 0",
+			attacker: AttackerProfile{Strength: 4, AP: 1},
+			target:   TargetProfile{Toughness: 4, APReduction: 2},
+			wantAP:   0,
+		},
+		{
+			// Heavy +1 and Stealth -1 cancel out; Lance +1 and the
+			// target's -1 to wound too.
+			name:     "Target modifiers stack with the attacker's",
+			attacker: AttackerProfile{Strength: 8, AP: 3, Heavy: true, Lance: true},
+			target:   TargetProfile{Toughness: 4, Stealth: true, MinusOneToWoundIfStronger: true, APReduction: 1},
+			wantAP:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared := []Modifier{{Source: "Aura", Value: 0}}
+			req := CombatSimulationRequest{
+				Attacker: tt.attacker,
+				Target:   tt.target,
+				Settings: SimulationSettings{
+					RemainedStationary: true,
+					Charged:            true,
+					HitModifiers:       shared,
+				},
+			}
+
+			applyTargetAbilities(&req)
+			applyWeaponAbilities(&req)
+
+			if req.Settings.HitModifier != tt.wantHitMod {
+				t.Errorf("HitModifier: got %d, want %d", req.Settings.HitModifier, tt.wantHitMod)
+			}
+			if req.Settings.WoundModifier != tt.wantWoundMod {
+				t.Errorf("WoundModifier: got %d, want %d", req.Settings.WoundModifier, tt.wantWoundMod)
+			}
+			if req.Attacker.AP != tt.wantAP {
+				t.Errorf("AP: got %d, want %d", req.Attacker.AP, tt.wantAP)
+			}
+			if len(shared) != 1 {
+				t.Errorf("shared settings modified: %+v", shared)
+			}
+		})
+	}
+}
+
+func TestCalculateDamageCore_TargetAbilities(t *testing.T) {
+	// A BS3+ S8 AP2 attack against a T4 Sv3+ target that has every
+	// defensive ability: hits on 4+, wounds on 4+ (not 3+: rolls of 1-3
+	// fail) and saves on 4+ against AP1.
+	req := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:    1,
+			Attacks:  DiceRoll{Modifier: 1},
+			BS:       3,
+			Strength: 8,
+			AP:       2,
+			Damage:   DiceRoll{Modifier: 1},
+		},
+		Target: TargetProfile{
+			Count:                     intPtr(1),
+			Toughness:                 4,
+			Save:                      3,
+			WoundsPerModel:            1,
+			Stealth:                   true,
+			MinusOneToWoundIfStronger: true,
+			APReduction:               1,
+			WoundRollsFailUpTo:        3,
+		},
+	}
+
+	calc := &DamageCalculatorImpl{}
+	resp, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifyValue(t, "AverageHits", resp.AverageHits, 3.0/6.0)
+	verifyDist(t, "WoundDist", resp.WoundDist, map[int]float64{0: 1.0 - 3.0/12.0, 1: 3.0 / 12.0})
+	verifyDist(t, "PenDist", resp.PenDist, map[int]float64{0: 1.0 - 3.0/24.0, 1: 3.0 / 24.0})
+	if resp.EffectiveHitModifier != -1 || resp.EffectiveWoundModifier != -1 {
+		t.Errorf("effective modifiers: got %+d/%+d, want -1/-1", resp.EffectiveHitModifier, resp.EffectiveWoundModifier)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "fmt"
+
+// targetLayout fixes the order in which a target unit's models are
+// allocated attacks: a model that has lost wounds always takes the next
+// one, the defender works through the non-Character groups in request
+// order, and Character groups come last.
+//
+// With a fixed order, the wounds the unit has left identify the destroyed
+// models and the wounds left on the model being allocated to. Precision
+// attacks may instead be allocated to the designated Character group
+// first, so when they are in play that group is kept on a track of its
+// own, and the allocation state (the index into every state vector) is
+// the pair (wounds left on the main track, wounds left on the character
+// track).
+type targetLayout struct {
+	groups []ModelGroup // request order
+
+	// character is the designated Character group, or -1 if there is
+	// none. It has a track of its own only when precision is set.
+	character int
+	precision bool
+	main      layoutTrack
+	directed  layoutTrack // the character track; empty unless precision
+
+	// precise is set on the view of the layout used by Precision attacks
+	// (see withPrecision).
+	precise bool
+
+	// start is the state of the unit before the attack: undamaged, or
+	// TargetProfile.WoundsRemaining, zero for a unit already destroyed.
+	// Destroyed models and wounds lost are counted from it.
+	start int
+}
+
+// layoutTrack is a sequence of models allocated to in order. Its slices
+// are indexed by the wounds left on the track; entry 0 is the empty track.
+type layoutTrack struct {
+	wounds  int
+	groupAt []int   // group of the model taking the next attack
+	hpAt    []int   // wounds left on that model
+	aliveAt [][]int // models alive per group
+}
+
+// newTargetLayout builds the allocation layout of a hydrated target. A
+// target without Groups is a single group of Count identical models.
+// Precision gives the designated Character group (see
+// TargetProfile.PrecisionTarget) a track of its own. A target with
+// WoundsRemaining starts with the wounds lost taken in allocation order,
+// the character track last.
+func newTargetLayout(target TargetProfile, precision bool) (*targetLayout, error) {
+	groups := target.Groups
+	if len(groups) == 0 {
+		groups = []ModelGroup{{
+			Count:          *target.Count,
+			WoundsPerModel: target.WoundsPerModel,
+			Save:           target.Save,
+			Invulnerable:   target.Invulnerable,
+			FeelNoPain:     target.FeelNoPain,
+		}}
+	}
+
+	l := &targetLayout{groups: groups, character: -1}
+	for i, g := range groups {
+		if !g.Character {
+			continue
+		}
+		if target.PrecisionTarget == "" || target.PrecisionTarget == g.Name {
+			l.character = i
+			break
+		}
+	}
+	if target.PrecisionTarget != "" && l.character < 0 {
+		return nil, fmt.Errorf("precision target %q is not a Character group", target.PrecisionTarget)
+	}
+	l.precision = precision && l.character >= 0
+
+	var mainOrder, characters []int
+	for i, g := range groups {
+		switch {
+		case l.precision && i == l.character:
+		case g.Character:
+			characters = append(characters, i)
+		default:
+			mainOrder = append(mainOrder, i)
+		}
+	}
+	l.main = newLayoutTrack(groups, append(mainOrder, characters...))
+	if l.precision {
+		l.directed = newLayoutTrack(groups, []int{l.character})
+	} else {
+		l.directed = newLayoutTrack(groups, nil)
+	}
+
+	l.start = l.totalStates() - 1
+	if target.WoundsRemaining != nil {
+		start, err := l.stateWithWoundsLeft(*target.WoundsRemaining)
+		if err != nil {
+			return nil, err
+		}
+		l.start = start
+	}
+	return l, nil
+}
+
+// stateWithWoundsLeft returns the state of a unit that has left wounds
+// left, the wounds lost taken in allocation order, the character track
+// last. Zero is the destroyed unit.
+func (l *targetLayout) stateWithWoundsLeft(left int) (int, error) {
+	total := l.main.wounds + l.directed.wounds
+	if left < 0 || left > total {
+		return 0, fmt.Errorf("wounds remaining must be between 0 and the unit's %d wounds, got %d", total, left)
+	}
+	mainLost := min(total-left, l.main.wounds)
+	return l.state(l.main.wounds-mainLost, l.directed.wounds-(total-left-mainLost)), nil
+}
+
+// startDist converts a PMF of the wounds the unit has left, as in
+// TargetProfile.WoundsRemainingDist, into a PMF of its state.
+func (l *targetLayout) startDist(woundsLeft map[int]float64) (map[int]float64, error) {
+	res := make(map[int]float64, len(woundsLeft))
+	for left, p := range woundsLeft {
+		s, err := l.stateWithWoundsLeft(left)
+		if err != nil {
+			return nil, err
+		}
+		res[s] += p
+	}
+	return res, nil
+}
+
+// newLayoutTrack lays out the models of the given groups, in allocation
+// order.
+func newLayoutTrack(groups []ModelGroup, order []int) layoutTrack {
+	t := layoutTrack{}
+	for _, gi := range order {
+		t.wounds += groups[gi].Count * groups[gi].WoundsPerModel
+	}
+	t.groupAt = make([]int, t.wounds+1)
+	t.hpAt = make([]int, t.wounds+1)
+	t.aliveAt = make([][]int, t.wounds+1)
+	t.aliveAt[0] = make([]int, len(groups))
+
+	// Walk the models from the last one allocated to the first: the states
+	// just above the wounds of the models behind it belong to it.
+	alive := make([]int, len(groups))
+	behind := 0
+	for i := len(order) - 1; i >= 0; i-- {
+		gi := order[i]
+		g := groups[gi]
+		for m := 0; m < g.Count; m++ {
+			alive[gi]++
+			for hp := 1; hp <= g.WoundsPerModel; hp++ {
+				s := behind + hp
+				t.groupAt[s] = gi
+				t.hpAt[s] = hp
+				t.aliveAt[s] = append([]int(nil), alive...)
+			}
+			behind += g.WoundsPerModel
+		}
+	}
+	return t
+}
+
+// withPrecision returns the view of the layout used by Precision attacks,
+// which are allocated to the designated Character group while it lives.
+func (l *targetLayout) withPrecision() *targetLayout {
+	c := *l
+	c.precise = l.precision
+	return &c
+}
+
+// withStart returns a copy of the layout that starts in state s, for a
+// target that earlier attacks have already damaged.
+func (l *targetLayout) withStart(s int) *targetLayout {
+	c := *l
+	c.start = s
+	return &c
+}
+
+// state returns the index of the state with m wounds left on the main
+// track and d on the character track.
+func (l *targetLayout) state(m, d int) int {
+	return m*(l.directed.wounds+1) + d
+}
+
+// split is the inverse of state.
+func (l *targetLayout) split(s int) (m, d int) {
+	return s / (l.directed.wounds + 1), s % (l.directed.wounds + 1)
+}
+
+// tracks returns the tracks of state s in the order they are allocated
+// to, with the wounds left on each.
+func (l *targetLayout) tracks(s int) (first, second *layoutTrack, firstLeft, secondLeft int) {
+	m, d := l.split(s)
+	if l.precise && d > 0 {
+		return &l.directed, &l.main, d, m
+	}
+	if m > 0 {
+		return &l.main, &l.directed, m, d
+	}
+	return &l.directed, &l.main, d, m
+}
+
+// groupAt returns the group of the model allocated to in state s (> 0).
+func (l *targetLayout) groupAt(s int) int {
+	first, _, left, _ := l.tracks(s)
+	return first.groupAt[left]
+}
+
+// lastGroup returns the group of the model allocated to last.
+func (l *targetLayout) lastGroup() int {
+	if l.directed.wounds > 0 && (!l.precise || l.main.wounds == 0) {
+		return l.directed.groupAt[1]
+	}
+	return l.main.groupAt[1]
+}
+
+// firstGroup returns the group the first attack is allocated to.
+func (l *targetLayout) firstGroup() ModelGroup {
+	if l.start == 0 {
+		return l.groups[l.lastGroup()]
+	}
+	return l.groups[l.groupAt(l.start)]
+}
+
+// totalStates returns the length of every state vector.
+func (l *targetLayout) totalStates() int {
+	return (l.main.wounds + 1) * (l.directed.wounds + 1)
+}
+
+// initialStates returns the state vector of the unit before the attack.
+func (l *targetLayout) initialStates() []float64 {
+	states := make([]float64, l.totalStates())
+	states[l.start] = 1.0
+	return states
+}
+
+// afterDamage returns the state reached when the model allocated to in
+// state s (> 0) suffers dmg damage. Without spills, damage in excess of
+// that model's wounds is lost; with spills it carries on to the next
+// model.
+func (l *targetLayout) afterDamage(s, dmg int, spills bool) int {
+	first, _, left, otherLeft := l.tracks(s)
+	if spills {
+		dealt := min(dmg, left)
+		left -= dealt
+		otherLeft = max(otherLeft-(dmg-dealt), 0)
+	} else {
+		left -= min(dmg, first.hpAt[left])
+	}
+	if first == &l.main {
+		return l.state(left, otherLeft)
+	}
+	return l.state(otherLeft, left)
+}
+
+// alive returns the models alive in each group in state s.
+func (l *targetLayout) alive(s int) []int {
+	m, d := l.split(s)
+	alive := make([]int, len(l.groups))
+	for gi := range alive {
+		alive[gi] = l.main.aliveAt[m][gi] + l.directed.aliveAt[d][gi]
+	}
+	return alive
+}
+
+// aliveCount returns the number of models alive in state s.
+func (l *targetLayout) aliveCount(s int) int {
+	total := 0
+	for _, a := range l.alive(s) {
+		total += a
+	}
+	return total
+}
+
+// aliveAtStart returns the number of models alive before the attack.
+func (l *targetLayout) aliveAtStart() int {
+	return l.aliveCount(l.start)
+}
+
+// killed converts a state vector into the PMF of destroyed models.
+func (l *targetLayout) killed(states []float64) []float64 {
+	total := l.aliveAtStart()
+	killed := make([]float64, total+1)
+	for s, prob := range states {
+		if prob < negligibleProbability {
+			continue
+		}
+		killed[total-l.aliveCount(s)] += prob
+	}
+	return killed
+}
+
+// groupKilled converts a state vector into the PMF of destroyed models
+// in each group, in request order.
+func (l *targetLayout) groupKilled(states []float64) [][]float64 {
+	start := l.alive(l.start)
+	res := make([][]float64, len(l.groups))
+	for gi := range l.groups {
+		res[gi] = make([]float64, start[gi]+1)
+	}
+	for s, prob := range states {
+		if prob < negligibleProbability {
+			continue
+		}
+		for gi, a := range l.alive(s) {
+			res[gi][start[gi]-a] += prob
+		}
+	}
+	return res
+}
+
+// woundsLost converts a state vector into the PMF of wounds the unit has
+// lost.
+func (l *targetLayout) woundsLost(states []float64) []float64 {
+	m, d := l.split(l.start)
+	total := m + d
+	lost := make([]float64, total+1)
+	for s, prob := range states {
+		if prob < negligibleProbability {
+			continue
+		}
+		m, d := l.split(s)
+		lost[total-m-d] += prob
+	}
+	return lost
+}
+
+// remainingWounds converts a state vector into the PMF of the wounds the
+// unit has left.
+func (l *targetLayout) remainingWounds(states []float64) []float64 {
+	remaining := make([]float64, l.main.wounds+l.directed.wounds+1)
+	for s, prob := range states {
+		m, d := l.split(s)
+		remaining[m+d] += prob
+	}
+	return remaining
+}
+
+// nextModelWounds converts a state vector into the PMF of the wounds left
+// on the model the next attack is allocated to: the wounded model if
+// there is one, 0 once the unit is destroyed.
+func (l *targetLayout) nextModelWounds(states []float64) []float64 {
+	maxWounds := 0
+	for _, g := range l.groups {
+		maxWounds = max(maxWounds, g.WoundsPerModel)
+	}
+	res := make([]float64, maxWounds+1)
+	for s, prob := range states {
+		if s == 0 {
+			res[0] += prob
+			continue
+		}
+		first, _, left, _ := l.tracks(s)
+		res[first.hpAt[left]] += prob
+	}
+	return res
+}
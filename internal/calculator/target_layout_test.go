@@ -0,0 +1,384 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import (
+	"testing"
+)
+
+func TestNewTargetLayout(t *testing.T) {
+	// The Character is listed first but allocated last; the 2-wound
+	// sergeant follows the single-wound troopers.
+	layout, err := newTargetLayout(TargetProfile{
+		Groups: []ModelGroup{
+			{Name: "Leader", Count: 1, WoundsPerModel: 3, Character: true},
+			{Name: "Troopers", Count: 2, WoundsPerModel: 1},
+			{Name: "Sergeant", Count: 1, WoundsPerModel: 2},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if layout.totalStates() != 8 {
+		t.Fatalf("totalStates: got %d, want 8", layout.totalStates())
+	}
+
+	// State = wounds left: 7,6 troopers; 5,4 sergeant; 3,2,1 Leader.
+	wantGroup := []int{0, 0, 0, 0, 2, 2, 1, 1}
+	wantHP := []int{0, 1, 2, 3, 1, 2, 1, 1}
+	for s := 1; s < layout.totalStates(); s++ {
+		if layout.groupAt(s) != wantGroup[s] || layout.main.hpAt[s] != wantHP[s] {
+			t.Errorf("state %d: got group %d with %d wounds, want group %d with %d wounds",
+				s, layout.groupAt(s), layout.main.hpAt[s], wantGroup[s], wantHP[s])
+		}
+	}
+
+	// Normal damage is lost at the end of a model, mortal wounds spill.
+	if got := layout.afterDamage(5, 3, false); got != 3 {
+		t.Errorf("afterDamage(5, 3, false): got %d, want 3", got)
+	}
+	if got := layout.afterDamage(5, 3, true); got != 2 {
+		t.Errorf("afterDamage(5, 3, true): got %d, want 2", got)
+	}
+
+	// Both troopers and the sergeant destroyed, Leader untouched.
+	states := make([]float64, layout.totalStates())
+	states[3] = 1.0
+	want := [][]float64{{1, 0}, {0, 0, 1}, {0, 1}}
+	got := layout.groupKilled(states)
+	for gi := range want {
+		for k := range want[gi] {
+			if got[gi][k] != want[gi][k] {
+				t.Errorf("group %d: got %v, want %v", gi, got[gi], want[gi])
+				break
+			}
+		}
+	}
+}
+
+func TestCalculateDamageCore_ModelGroups(t *testing.T) {
+	// Torrent, S8 vs T4: every attack wounds on a 2+ (5/6).
+	base := func(attacks, damage int, groups []ModelGroup) CombatSimulationRequest {
+		return CombatSimulationRequest{
+			Attacker: AttackerProfile{
+				Count:    1,
+				Attacks:  DiceRoll{Modifier: attacks},
+				Torrent:  true,
+				Strength: 8,
+				Damage:   DiceRoll{Modifier: damage},
+			},
+			Target: TargetProfile{Toughness: 4, Groups: groups},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		req                CombatSimulationRequest
+		expectedDestroyed  map[int]float64
+		expectedGroups     []map[int]float64
+		expectedDamage     map[int]float64
+		expectedWoundsLost map[int]float64
+	}{
+		{
+			// The Leader has the better save but is only allocated to once
+			// the troopers are gone.
+			name: "Character allocated last",
+			req: base(1, 1, []ModelGroup{
+				{Name: "Leader", Count: 1, WoundsPerModel: 3, Save: 2, Character: true},
+				{Name: "Troopers", Count: 2, WoundsPerModel: 1, Save: 7},
+			}),
+			expectedDestroyed: map[int]float64{0: 1.0 / 6.0, 1: 5.0 / 6.0},
+			expectedGroups: []map[int]float64{
+				{0: 1.0},
+				{0: 1.0 / 6.0, 1: 5.0 / 6.0},
+			},
+			expectedDamage: map[int]float64{0: 1.0 / 6.0, 1: 5.0 / 6.0},
+		},
+		{
+			// Damage 2: the first attack takes the 1-wound trooper (the
+			// unit loses 1 wound of it); the second only reaches the
+			// sergeant, who saves on a 4+, if the first one succeeded.
+			name: "Each group rolls its own save",
+			req: base(2, 2, []ModelGroup{
+				{Name: "Trooper", Count: 1, WoundsPerModel: 1, Save: 7},
+				{Name: "Sergeant", Count: 1, WoundsPerModel: 2, Save: 4},
+			}),
+			expectedDestroyed: map[int]float64{0: 1.0 / 36.0, 1: 45.0 / 72.0, 2: 25.0 / 72.0},
+			expectedGroups: []map[int]float64{
+				{0: 1.0 / 36.0, 1: 35.0 / 36.0},
+				{0: 47.0 / 72.0, 1: 25.0 / 72.0},
+			},
+			expectedDamage:     map[int]float64{0: 1.0 / 36.0, 2: 45.0 / 72.0, 4: 25.0 / 72.0},
+			expectedWoundsLost: map[int]float64{0: 1.0 / 36.0, 1: 45.0 / 72.0, 3: 25.0 / 72.0},
+		},
+		{
+			// A 4+ Feel No Pain on the only group halves each wound.
+			name: "Per-group Feel No Pain",
+			req: base(1, 1, []ModelGroup{
+				{Name: "Troopers", Count: 2, WoundsPerModel: 1, Save: 7, FeelNoPain: intPtr(4)},
+			}),
+			expectedDestroyed: map[int]float64{0: 7.0 / 12.0, 1: 5.0 / 12.0},
+			expectedGroups: []map[int]float64{
+				{0: 7.0 / 12.0, 1: 5.0 / 12.0},
+			},
+			expectedDamage: map[int]float64{0: 7.0 / 12.0, 1: 5.0 / 12.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := &DamageCalculatorImpl{}
+			resp, err := calc.CalculateDamageCore(tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			verifyDist(t, "DestroyedDist", resp.DestroyedDist, tt.expectedDestroyed)
+			verifyDist(t, "DamageDist", resp.DamageDist, tt.expectedDamage)
+			woundsLost := tt.expectedWoundsLost
+			if woundsLost == nil {
+				woundsLost = tt.expectedDamage
+			}
+			verifyDist(t, "WoundsLostDist", resp.WoundsLostDist, woundsLost)
+			if len(resp.Groups) != len(tt.expectedGroups) {
+				t.Fatalf("Groups: got %d groups, want %d", len(resp.Groups), len(tt.expectedGroups))
+			}
+			for i, want := range tt.expectedGroups {
+				if resp.Groups[i].Name != tt.req.Target.Groups[i].Name {
+					t.Errorf("Groups[%d]: got name %q, want %q", i, resp.Groups[i].Name, tt.req.Target.Groups[i].Name)
+				}
+				verifyDist(t, resp.Groups[i].Name, resp.Groups[i].DestroyedDist, want)
+			}
+		})
+	}
+}
+
+func TestTargetLayout_Precision(t *testing.T) {
+	target := TargetProfile{
+		Groups: []ModelGroup{
+			{Name: "Troopers", Count: 2, WoundsPerModel: 1},
+			{Name: "Leader", Count: 1, WoundsPerModel: 2, Character: true},
+		},
+	}
+	layout, err := newTargetLayout(target, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := layout.totalStates() - 1
+
+	// Mortal wounds spill from the first track allocated to into the other.
+	if got, want := layout.withPrecision().afterDamage(start, 3, true), layout.state(1, 0); got != want {
+		t.Errorf("precision: got state %d, want %d", got, want)
+	}
+	if got, want := layout.afterDamage(start, 3, true), layout.state(0, 1); got != want {
+		t.Errorf("no precision: got state %d, want %d", got, want)
+	}
+	if g := layout.withPrecision().firstGroup(); g.Name != "Leader" {
+		t.Errorf("precision: first group %q, want Leader", g.Name)
+	}
+
+	target.PrecisionTarget = "Troopers"
+	if _, err := newTargetLayout(target, true); err == nil {
+		t.Error("expected an error for a PrecisionTarget that is not a Character group")
+	}
+}
+
+func TestCalculateDamageCore_Precision(t *testing.T) {
+	// Torrent, S8 vs T4, no save: every attack destroys a 1-wound model
+	// with probability 5/6.
+	profile := func(precision bool) AttackerProfile {
+		return AttackerProfile{
+			Count:     1,
+			Attacks:   DiceRoll{Modifier: 1},
+			Torrent:   true,
+			Strength:  8,
+			Damage:    DiceRoll{Modifier: 1},
+			Precision: precision,
+		}
+	}
+	base := func(precision bool) CombatSimulationRequest {
+		return CombatSimulationRequest{
+			Attacker: profile(precision),
+			Target: TargetProfile{
+				Toughness: 4,
+				Groups: []ModelGroup{
+					{Name: "Troopers", Count: 2, WoundsPerModel: 1, Save: 7},
+					{Name: "Leader", Count: 1, WoundsPerModel: 1, Save: 7, Character: true},
+				},
+			},
+		}
+	}
+
+	withWeapon := base(true)
+	withWeapon.Weapons = []AttackerProfile{profile(false)}
+
+	tests := []struct {
+		name              string
+		req               CombatSimulationRequest
+		expectedDestroyed map[int]float64
+		expectedGroups    []map[int]float64
+		expectedCharacter float64
+	}{
+		{
+			name:              "Without Precision the Leader is allocated last",
+			req:               base(false),
+			expectedDestroyed: map[int]float64{0: 1.0 / 6.0, 1: 5.0 / 6.0},
+			expectedGroups:    []map[int]float64{{0: 1.0 / 6.0, 1: 5.0 / 6.0}, {0: 1.0}},
+			expectedCharacter: 0,
+		},
+		{
+			name:              "Precision targets the Leader",
+			req:               base(true),
+			expectedDestroyed: map[int]float64{0: 1.0 / 6.0, 1: 5.0 / 6.0},
+			expectedGroups:    []map[int]float64{{0: 1.0}, {0: 1.0 / 6.0, 1: 5.0 / 6.0}},
+			expectedCharacter: 5.0 / 6.0,
+		},
+		{
+			// The second profile has no Precision: the defender allocates
+			// it to the troopers whether or not the Leader survived.
+			name:              "Precision profile followed by a normal one",
+			req:               withWeapon,
+			expectedDestroyed: map[int]float64{0: 1.0 / 36.0, 1: 10.0 / 36.0, 2: 25.0 / 36.0},
+			expectedGroups:    []map[int]float64{{0: 1.0 / 6.0, 1: 5.0 / 6.0}, {0: 1.0 / 6.0, 1: 5.0 / 6.0}},
+			expectedCharacter: 5.0 / 6.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := &DamageCalculatorImpl{}
+			resp, err := calc.CalculateDamageCore(tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			verifyDist(t, "DestroyedDist", resp.DestroyedDist, tt.expectedDestroyed)
+			for i, want := range tt.expectedGroups {
+				verifyDist(t, resp.Groups[i].Name, resp.Groups[i].DestroyedDist, want)
+			}
+			verifyValue(t, "CharacterDestroyedProb", resp.CharacterDestroyedProb, tt.expectedCharacter)
+		})
+	}
+}
+
+func TestNewTargetLayout_WoundsRemaining(t *testing.T) {
+	target := TargetProfile{
+		Groups: []ModelGroup{
+			{Name: "Troopers", Count: 2, WoundsPerModel: 2},
+			{Name: "Leader", Count: 1, WoundsPerModel: 3, Character: true},
+		},
+		WoundsRemaining: intPtr(4),
+	}
+
+	// 3 wounds lost: a trooper destroyed and the other wounded.
+	layout, err := newTargetLayout(target, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if layout.start != 4 || layout.aliveAtStart() != 2 {
+		t.Errorf("start: got state %d with %d alive, want state 4 with 2 alive", layout.start, layout.aliveAtStart())
+	}
+	verifyDist(t, "nextModelWounds", vectorToMap(layout.nextModelWounds(layout.initialStates())), map[int]float64{1: 1.0})
+
+	// With Precision the Leader keeps its wounds on its own track.
+	layout, err = newTargetLayout(target, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := layout.state(1, 3); layout.start != want {
+		t.Errorf("precision start: got state %d, want %d", layout.start, want)
+	}
+
+	// Destroyed models and wounds lost are counted from the start.
+	states := make([]float64, layout.totalStates())
+	states[layout.state(0, 2)] = 1.0
+	verifyDist(t, "killed", vectorToMap(layout.killed(states)), map[int]float64{1: 1.0})
+	verifyDist(t, "woundsLost", vectorToMap(layout.woundsLost(states)), map[int]float64{2: 1.0})
+	verifyDist(t, "remainingWounds", vectorToMap(layout.remainingWounds(states)), map[int]float64{2: 1.0})
+	verifyDist(t, "nextModelWounds", vectorToMap(layout.nextModelWounds(states)), map[int]float64{2: 1.0})
+
+	for _, left := range []int{-1, 8} {
+		target.WoundsRemaining = intPtr(left)
+		if _, err := newTargetLayout(target, false); err == nil {
+			t.Errorf("expected an error for %d wounds remaining of 7", left)
+		}
+	}
+}
+
+func TestCalculateDamageCore_GroupsDealSameDamage(t *testing.T) {
+	// A unit described as a single group reports what the same unit
+	// described by Count and WoundsPerModel does, excess damage included.
+	plain := func() CombatSimulationRequest {
+		req := generateBaseRequest()
+		req.Attacker.Count = 3
+		req.Attacker.Damage = DiceRoll{Count: 1, Sides: 3}
+		req.Target.Count = intPtr(3)
+		req.Target.FeelNoPain = intPtr(5)
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*CombatSimulationRequest)
+	}{
+		{"Rolled damage", func(*CombatSimulationRequest) {}},
+		{"Mortal wounds", func(req *CombatSimulationRequest) {
+			req.Attacker.MortalWounds = DiceRoll{Modifier: 1}
+			req.Attacker.MortalWoundsOn = MortalWoundsOnCriticalHit
+		}},
+		{"Damage reroll", func(req *CombatSimulationRequest) {
+			req.Settings.SingleReroll.Damage = true
+		}},
+		{"Save reroll", func(req *CombatSimulationRequest) {
+			req.Settings.SingleReroll.Save = true
+		}},
+		{"Several profiles", func(req *CombatSimulationRequest) {
+			req.Weapons = []AttackerProfile{req.Attacker}
+		}},
+	}
+
+	calc := &DamageCalculatorImpl{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := plain()
+			tt.modify(&req)
+			want, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			req.Target.Groups = []ModelGroup{{
+				Count:          *req.Target.Count,
+				WoundsPerModel: req.Target.WoundsPerModel,
+				Save:           req.Target.Save,
+				FeelNoPain:     req.Target.FeelNoPain,
+			}}
+			got, err := calc.CalculateDamageCore(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			verifyDist(t, "DamageDist", got.DamageDist, want.DamageDist)
+			verifyDist(t, "WoundsLostDist", got.WoundsLostDist, want.WoundsLostDist)
+			verifyDist(t, "DestroyedDist", got.DestroyedDist, want.DestroyedDist)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+// applyWeaponAbilities folds the situational weapon abilities into the
+// plain modifiers, rerolls and cover flag the rest of the pipeline reads:
+//
+//   - [HEAVY]: +1 to hit if the attacker Remained Stationary.
+//   - [LANCE]: +1 to wound if the attacker Charged.
+//   - [TWIN-LINKED]: wound rolls can be re-rolled.
+//   - [INDIRECT FIRE]: against a target that is not visible, -1 to hit and
+//     the target has the Benefit of Cover.
+//   - [IGNORES COVER]: the target never has the Benefit of Cover.
+//   - Fire Overwatch (FiringModeOverwatch): only Critical Hits hit, unless
+//     the weapon has [TORRENT]. BS becomes overwatchBS, which no modified
+//     roll reaches.
+//
+// Every modifier source — the settings, the profile and these abilities
+// — is then summed into a single hit and wound modifier, capped at +1/-1
+// as the core rules require.
+//
+// It runs after Hydrate, so every stage downstream sees a single, already
+// resolved set of modifiers instead of re-checking keywords.
+func applyWeaponAbilities(req *CombatSimulationRequest) {
+	a := req.Attacker
+	s := &req.Settings
+
+	hitMods := modifierSources(s.HitModifier, s.HitModifiers, a.HitModifier, a.HitModifiers)
+	woundMods := modifierSources(s.WoundModifier, s.WoundModifiers, a.WoundModifier, a.WoundModifiers)
+
+	if a.Heavy && s.RemainedStationary {
+		hitMods = append(hitMods, Modifier{Source: "Heavy", Value: 1})
+	}
+	if a.IndirectFire && s.TargetNotVisible {
+		hitMods = append(hitMods, Modifier{Source: "Indirect Fire", Value: -1})
+		req.Target.HasCover = true
+	}
+	if a.Lance && s.Charged {
+		woundMods = append(woundMods, Modifier{Source: "Lance", Value: 1})
+	}
+
+	s.HitModifier = netModifier(hitMods, a.IgnoreHitModifiers)
+	s.WoundModifier = netModifier(woundMods, a.IgnoreWoundModifiers)
+	if a.TwinLinked {
+		s.WoundReroll = twinLinkedReroll(s.WoundReroll)
+	}
+
+	if s.FiringMode == FiringModeOverwatch && !a.Torrent {
+		req.Attacker.BS = overwatchBS
+	}
+
+	// Ignores Cover is resolved last so it also strips cover granted by
+	// Indirect Fire.
+	if a.IgnoresCover {
+		req.Target.HasCover = false
+	}
+}
+
+// overwatchBS is the BS of an attack fired in Overwatch. A modified hit
+// roll is never above 6, so only Critical Hits hit.
+const overwatchBS = 7
+
+// twinLinkedReroll upgrades a wound reroll to a full re-roll of failed
+// wound rolls; stronger reroll rules are kept as they are.
+func twinLinkedReroll(current RerollType) RerollType {
+	if current == RerollNone || current == RerollOnes {
+		return RerollFail
+	}
+	return current
+}
+
+// modifierSources lists the modifier sources of the settings and the
+// profile; the plain values count as unnamed sources.
+func modifierSources(settings int, settingsMods []Modifier, profile int, profileMods []Modifier) []Modifier {
+	mods := []Modifier{{Value: settings}, {Value: profile}}
+	mods = append(mods, settingsMods...)
+	return append(mods, profileMods...)
+}
+
+// netModifier sums modifier sources and caps the result at +1/-1. An
+// attacker that may ignore modifiers ignores every penalty.
+func netModifier(mods []Modifier, ignorePenalties bool) int {
+	net := 0
+	for _, m := range mods {
+		if ignorePenalties && m.Value < 0 {
+			continue
+		}
+		net += m.Value
+	}
+	return clamp(net, -1, 1)
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2026 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package calculator
+
+import "testing"
+
+func TestApplyWeaponAbilities(t *testing.T) {
+	tests := []struct {
+		name          string
+		attacker      AttackerProfile
+		settings      SimulationSettings
+		hasCover      bool
+		wantHitMod    int
+		wantWoundMod  int
+		wantWoundRoll RerollType
+		wantCover     bool
+	}{
+		{
+			name:          "Heavy while stationary: +1 to hit",
+			attacker:      AttackerProfile{Heavy: true},
+			settings:      SimulationSettings{RemainedStationary: true},
+			wantHitMod:    1,
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name:          "Heavy after moving: no bonus",
+			attacker:      AttackerProfile{Heavy: true},
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name:          "Lance on the charge is capped with an existing wound modifier",
+			attacker:      AttackerProfile{Lance: true},
+			settings:      SimulationSettings{Charged: true, WoundModifier: 1},
+			wantWoundMod:  1,
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name:     "Named sources are summed before the cap",
+			attacker: AttackerProfile{Heavy: true, HitModifiers: []Modifier{{Source: "Aura", Value: 1}}},
+			settings: SimulationSettings{
+				RemainedStationary: true,
+				HitModifiers:       []Modifier{{Source: "Stealth", Value: -1}, {Source: "Smoke", Value: -1}},
+				WoundModifiers:     []Modifier{{Source: "Debuff", Value: -1}, {Source: "Curse", Value: -1}},
+			},
+			wantHitMod:    0,
+			wantWoundMod:  -1,
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name: "Ignoring modifiers drops the penalties only",
+			attacker: AttackerProfile{
+				IgnoreHitModifiers: true,
+				HitModifiers:       []Modifier{{Source: "Aura", Value: 1}},
+			},
+			settings: SimulationSettings{
+				HitModifiers:   []Modifier{{Source: "Stealth", Value: -1}},
+				WoundModifiers: []Modifier{{Source: "Debuff", Value: -1}},
+			},
+			wantHitMod:    1,
+			wantWoundMod:  -1,
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name:          "Profile modifiers add to the shared settings",
+			attacker:      AttackerProfile{HitModifier: 1, WoundModifier: -1},
+			settings:      SimulationSettings{HitModifier: -1},
+			wantHitMod:    0,
+			wantWoundMod:  -1,
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name:          "Twin-linked upgrades reroll ones to reroll fails",
+			attacker:      AttackerProfile{TwinLinked: true},
+			settings:      SimulationSettings{WoundReroll: RerollOnes},
+			wantWoundRoll: RerollFail,
+		},
+		{
+			name:          "Indirect Fire at a hidden target: -1 to hit and cover",
+			attacker:      AttackerProfile{IndirectFire: true},
+			settings:      SimulationSettings{TargetNotVisible: true},
+			wantHitMod:    -1,
+			wantWoundRoll: RerollNone,
+			wantCover:     true,
+		},
+		{
+			name:          "Indirect Fire at a visible target: no penalty",
+			attacker:      AttackerProfile{IndirectFire: true},
+			wantWoundRoll: RerollNone,
+		},
+		{
+			name:          "Ignores Cover strips both real and Indirect Fire cover",
+			attacker:      AttackerProfile{IndirectFire: true, IgnoresCover: true},
+			settings:      SimulationSettings{TargetNotVisible: true},
+			hasCover:      true,
+			wantHitMod:    -1,
+			wantWoundRoll: RerollNone,
+			wantCover:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CombatSimulationRequest{
+				Attacker: tt.attacker,
+				Target:   TargetProfile{HasCover: tt.hasCover},
+				Settings: tt.settings,
+			}
+
+			applyWeaponAbilities(&req)
+
+			if req.Settings.HitModifier != tt.wantHitMod {
+				t.Errorf("HitModifier: got %d, want %d", req.Settings.HitModifier, tt.wantHitMod)
+			}
+			if req.Settings.WoundModifier != tt.wantWoundMod {
+				t.Errorf("WoundModifier: got %d, want %d", req.Settings.WoundModifier, tt.wantWoundMod)
+			}
+			if req.Settings.WoundReroll != tt.wantWoundRoll {
+				t.Errorf("WoundReroll: got %v, want %v", req.Settings.WoundReroll, tt.wantWoundRoll)
+			}
+			if req.Target.HasCover != tt.wantCover {
+				t.Errorf("HasCover: got %v, want %v", req.Target.HasCover, tt.wantCover)
+			}
+		})
+	}
+}
+
+func TestCalculateDamageCore_HeavyStationary(t *testing.T) {
+	// BS4+ Heavy weapon: hits on 4+ normally, 3+ after remaining stationary.
+	req := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:    1,
+			Attacks:  DiceRoll{Modifier: 1},
+			BS:       4,
+			Strength: 4,
+			Damage:   DiceRoll{Modifier: 1},
+			Heavy:    true,
+		},
+		Target: TargetProfile{
+			Count:          intPtr(1),
+			Toughness:      4,
+			Save:           7,
+			WoundsPerModel: 1,
+		},
+		Settings: SimulationSettings{RemainedStationary: true},
+	}
+
+	calc := &DamageCalculatorImpl{}
+	resp, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifyValue(t, "AverageHits", resp.AverageHits, 4.0/6.0)
+	verifyValue(t, "AverageDestroyed", resp.AverageDestroyed, 4.0/6.0*3.0/6.0)
+}
+
+func TestCalculateDamageCore_EffectiveModifiers(t *testing.T) {
+	// Heavy and an aura would give +2 to hit; the roll only gets +1, so a
+	// BS4+ attack hits on a 3+.
+	req := CombatSimulationRequest{
+		Attacker: AttackerProfile{
+			Count:        1,
+			Attacks:      DiceRoll{Modifier: 1},
+			BS:           4,
+			Strength:     4,
+			Damage:       DiceRoll{Modifier: 1},
+			Heavy:        true,
+			HitModifiers: []Modifier{{Source: "Aura", Value: 1}},
+		},
+		Weapons: []AttackerProfile{{
+			Count:    1,
+			Attacks:  DiceRoll{Modifier: 1},
+			BS:       4,
+			Strength: 4,
+			Damage:   DiceRoll{Modifier: 1},
+		}},
+		Target: TargetProfile{
+			Count:          intPtr(2),
+			Toughness:      4,
+			Save:           7,
+			WoundsPerModel: 1,
+		},
+		Settings: SimulationSettings{
+			RemainedStationary: true,
+			WoundModifiers:     []Modifier{{Source: "Stealth", Value: -1}, {Source: "Debuff", Value: -1}},
+		},
+	}
+
+	calc := &DamageCalculatorImpl{}
+	resp, err := calc.CalculateDamageCore(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.EffectiveHitModifier != 1 || resp.EffectiveWoundModifier != -1 {
+		t.Errorf("effective modifiers: got %+d/%+d, want +1/-1", resp.EffectiveHitModifier, resp.EffectiveWoundModifier)
+	}
+	if w := resp.Weapons[1]; w.EffectiveHitModifier != 0 || w.EffectiveWoundModifier != -1 {
+		t.Errorf("Weapons[1] effective modifiers: got %+d/%+d, want 0/-1", w.EffectiveHitModifier, w.EffectiveWoundModifier)
+	}
+	verifyValue(t, "Weapons[0].AverageHits", resp.Weapons[0].AverageHits, 4.0/6.0)
+}
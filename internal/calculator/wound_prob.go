@@ -22,10 +22,112 @@ package calculator
 
 import (
 	"math"
+	"strings"
 )
 
+// WoundOutcome is the discrete result of a single wound roll.
+type WoundOutcome int
+
+const (
+	// WoundFailed is a failed wound roll.
+	WoundFailed WoundOutcome = iota
+	// WoundNormal is a successful wound roll that is not a Critical Wound.
+	WoundNormal
+	// WoundCritical is a Critical Wound on the weapon's own threshold,
+	// devastating if it has [DEVASTATING WOUNDS].
+	WoundCritical
+	// WoundAntiCritical is a Critical Wound only thanks to an
+	// [ANTI-KEYWORD X+] ability, also devastating with [DEVASTATING WOUNDS].
+	WoundAntiCritical
+)
+
+// CalculateSingleWoundDistribution returns the PMF of the outcome of a
+// single wound roll. Like CalculateSingleHitDistribution, it resolves the
+// roll face by face, so rerolls compose exactly with Critical Wounds: a
+// Critical Wound is a success and is never rerolled as a failure.
+//
+// Arguments:
+// s (int): Weapon Strength.
+// t (int): Target Toughness.
+// rerollType (RerollType): Type of reroll (none, ones, fail, non_crit).
+// woundModifier (int): Modifier to the wound roll.
+// criticalWoundThreshold (int): Explicit Critical Wound threshold (e.g., 5 for 5+).
+// anti ([]AntiKeyword): The weapon's [ANTI-KEYWORD X+] abilities.
+// targetKeywords ([]string): Keywords of the target unit, matched against anti.
+// failUpTo (int): Unmodified rolls up to this value always fail, whatever
+// the weapon's abilities (e.g., 3 for Transhuman-style abilities; 0 for none).
+func CalculateSingleWoundDistribution(s int, t int, rerollType RerollType, woundModifier int,
+	criticalWoundThreshold int, anti []AntiKeyword, targetKeywords []string, failUpTo int) map[WoundOutcome]float64 {
+
+	outcomeOf := woundFaceOutcomes(s, t, woundModifier, criticalWoundThreshold, anti, targetKeywords, failUpTo)
+	failed, critical := woundFaceChecks(outcomeOf)
+	faceProbs := rerollFaces(rerollType, failed, critical)
+
+	dist := make(map[WoundOutcome]float64)
+	for face := 1; face <= 6; face++ {
+		if prob := faceProbs[face]; prob > 0 {
+			dist[outcomeOf(face)] += prob
+		}
+	}
+	return dist
+}
+
+// singleWoundRerollEligible returns the chance that a wound roll fails
+// without having been rerolled, so that a single-die reroll can be spent
+// on it (see singleRerollEligible).
+func singleWoundRerollEligible(s int, t int, rerollType RerollType, woundModifier int,
+	criticalWoundThreshold int, anti []AntiKeyword, targetKeywords []string, failUpTo int) float64 {
+	failed, critical := woundFaceChecks(woundFaceOutcomes(s, t, woundModifier, criticalWoundThreshold, anti, targetKeywords, failUpTo))
+	return singleRerollEligible(rerollType, failed, critical)
+}
+
+// woundFaceOutcomes returns the outcome of each unmodified wound roll face.
+func woundFaceOutcomes(s int, t int, woundModifier int,
+	criticalWoundThreshold int, anti []AntiKeyword, targetKeywords []string, failUpTo int) func(face int) WoundOutcome {
+	target := int(clampWoundTarget(woundRollTarget(s, t), woundModifier))
+	critThreshold := sanitizeCriticalThreshold(criticalWoundThreshold)
+	antiThreshold := sanitizeCriticalThreshold(antiCriticalWoundThreshold(criticalWoundThreshold, anti, targetKeywords))
+
+	return func(face int) WoundOutcome {
+		if face <= failUpTo {
+			return WoundFailed
+		}
+		return resolveWoundOutcome(face, target, critThreshold, antiThreshold)
+	}
+}
+
+// woundFaceChecks returns whether a wound roll face fails and whether it
+// is a Critical Wound.
+func woundFaceChecks(outcomeOf func(face int) WoundOutcome) (failed, critical func(face int) bool) {
+	failed = func(face int) bool {
+		return outcomeOf(face) == WoundFailed
+	}
+	critical = func(face int) bool {
+		outcome := outcomeOf(face)
+		return outcome == WoundCritical || outcome == WoundAntiCritical
+	}
+	return failed, critical
+}
+
+// resolveWoundOutcome determines what happens on a specific physical wound
+// roll. Critical Wounds are based on the unmodified roll and always
+// succeed; the modified target is already clamped to [2, 6], so a natural
+// 1 always fails.
+func resolveWoundOutcome(face, target, critThreshold, antiThreshold int) WoundOutcome {
+	switch {
+	case face >= critThreshold:
+		return WoundCritical
+	case face >= antiThreshold:
+		return WoundAntiCritical
+	case face >= target:
+		return WoundNormal
+	default:
+		return WoundFailed
+	}
+}
+
 // CalculateWoundProbability calculates the probability that a single successful hit
-// will result in a wound on the target.
+// will result in a wound on the target, from CalculateSingleWoundDistribution.
 //
 // Arguments:
 // s (int): Weapon Strength.
@@ -33,40 +135,29 @@ import (
 // rerollType (RerollType): Type of reroll (none, ones, fail).
 // woundModifier (int): Modifier to the wound roll.
 // devastatingWounds (bool): Presence of the [DEVASTATING WOUNDS] ability.
+// CriticalWoundThreshold (int): Explicit Critical Wound threshold (e.g., 5 for 5+).
+// anti ([]AntiKeyword): The weapon's [ANTI-KEYWORD X+] abilities.
+// targetKeywords ([]string): Keywords of the target unit, matched against anti.
+// failUpTo (int): Unmodified rolls up to this value always fail (0 for none).
 //
 // Returns:
 // (float64, float64): Probability of a normal wound and a devastating wound.
 func CalculateWoundProbability(s int, t int, rerollType RerollType, woundModifier int, devastatingWounds bool,
-	CriticalWoundThreshold int) (float64, float64) {
-	const oneSixth = 1.0 / 6.0
-
-	finalTargetRoll := clampWoundTarget(woundRollTarget(s, t), woundModifier)
-	woundChance := chanceOfRollingAtLeast(finalTargetRoll)
-
-	critChance := chanceOfRollingAtLeast(float64(sanitizeCriticalThreshold(CriticalWoundThreshold)))
-
-	// Critical Wounds are always successful: if critChance is higher than
-	// woundChance (e.g., Anti-2+ vs T12), woundChance is elevated to match.
-	if critChance > woundChance {
-		woundChance = critChance
-	}
+	CriticalWoundThreshold int, anti []AntiKeyword, targetKeywords []string, failUpTo int) (float64, float64) {
+	dist := CalculateSingleWoundDistribution(s, t, rerollType, woundModifier, CriticalWoundThreshold, anti, targetKeywords, failUpTo)
+	return splitWoundOutcomes(dist, devastatingWounds)
+}
 
-	missChance := 1.0 - woundChance
-
-	switch rerollType {
-	case RerollOnes:
-		// Only a natural 1 can be rerolled.
-		woundChance = applyRerollBonus(woundChance, oneSixth)
-		critChance = applyRerollBonus(critChance, oneSixth)
-	case RerollFail:
-		// Every failed roll can be rerolled.
-		woundChance = applyRerollBonus(woundChance, missChance)
-		critChance = applyRerollBonus(critChance, missChance)
+// splitWoundOutcomes returns the probabilities of a normal and of a
+// devastating wound. Per the core rules, [DEVASTATING WOUNDS] Critical
+// Wounds bypass the save roll entirely, so they must be resolved
+// separately from ordinary wounds; without it they are ordinary wounds.
+func splitWoundOutcomes(dist map[WoundOutcome]float64, devastatingWounds bool) (normal, devastating float64) {
+	critical := dist[WoundCritical] + dist[WoundAntiCritical]
+	if !devastatingWounds {
+		return dist[WoundNormal] + critical, 0.0
 	}
-
-	woundChance, devastatingWoundChance := splitDevastatingWounds(woundChance, critChance, devastatingWounds)
-
-	return woundChance, devastatingWoundChance
+	return dist[WoundNormal], critical
 }
 
 // woundRollTarget returns the unmodified D6 roll needed to wound. Per the
@@ -109,20 +200,36 @@ func sanitizeCriticalThreshold(v int) int {
 	return v
 }
 
-// applyRerollBonus adds the probability of succeeding on a reroll: a second
-// attempt happens with probability retryChance, and succeeds at the same
-// rate as the original roll, so the bonus is retryChance*chance.
-func applyRerollBonus(chance, retryChance float64) float64 {
-	return chance + retryChance*chance
+// antiCriticalWoundThreshold returns the best (lowest) Critical Wound
+// threshold out of the explicit one and every [ANTI-KEYWORD X+] ability whose
+// keyword the target has. Keywords match case-insensitively, so "Infantry"
+// and "INFANTRY" are the same keyword. An explicit threshold of 0 means
+// "not supplied" and never wins over a matching Anti ability; Anti
+// thresholds outside the rollable [2, 6] range are ignored.
+func antiCriticalWoundThreshold(explicit int, anti []AntiKeyword, targetKeywords []string) int {
+	best := explicit
+	for _, a := range anti {
+		if a.Threshold < 2 || a.Threshold > 6 || !hasKeyword(targetKeywords, a.Keyword) {
+			continue
+		}
+		if best == 0 || a.Threshold < best {
+			best = a.Threshold
+		}
+	}
+	return best
 }
 
-// splitDevastatingWounds separates the devastating-wound share out of the
-// total wound chance. Per the core rules, [DEVASTATING WOUNDS] wounds
-// bypass the save roll entirely, so they must be resolved separately from
-// ordinary wounds rather than folded into woundChance.
-func splitDevastatingWounds(woundChance, critChance float64, devastatingWounds bool) (normal, devastating float64) {
-	if !devastatingWounds {
-		return woundChance, 0.0
+// hasKeyword reports whether keyword is one of keywords, ignoring case and
+// surrounding whitespace.
+func hasKeyword(keywords []string, keyword string) bool {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return false
+	}
+	for _, k := range keywords {
+		if strings.EqualFold(strings.TrimSpace(k), keyword) {
+			return true
+		}
 	}
-	return math.Max(0.0, woundChance-critChance), critChance
+	return false
 }
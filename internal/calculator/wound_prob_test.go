@@ -39,6 +39,8 @@ func TestCalculateWoundProbability(t *testing.T) {
 		woundModifier            int
 		devastatingWounds        bool
 		criticalWoundThreshold   int // Added field
+		anti                     []AntiKeyword
+		targetKeywords           []string
 		expectedNormalWound      float64
 		expectedDevastatingWound float64
 	}{
@@ -95,6 +97,58 @@ func TestCalculateWoundProbability(t *testing.T) {
 			expectedNormalWound:      0.0,       // All successes are diverted to Devastating
 			expectedDevastatingWound: 5.0 / 6.0, // 2,3,4,5,6 are all Crits
 		},
+		// --- Anti-X derived from target keywords ---
+		{
+			// Anti-Infantry 4+ vs an Infantry target: S3 vs T4 normally wounds on 5+,
+			// but 4+ is now a Critical Wound and therefore a success.
+			name:                     "Anti-Infantry 4+ vs matching keyword",
+			s:                        3,
+			t:                        4,
+			rerollType:               RerollNone,
+			criticalWoundThreshold:   6,
+			anti:                     []AntiKeyword{{Keyword: "Infantry", Threshold: 4}},
+			targetKeywords:           []string{"INFANTRY", "Imperium"},
+			expectedNormalWound:      3.0 / 6.0,
+			expectedDevastatingWound: 0.0,
+		},
+		{
+			name:                     "Anti-Vehicle 2+ vs non-matching keyword",
+			s:                        3,
+			t:                        4,
+			rerollType:               RerollNone,
+			criticalWoundThreshold:   6,
+			anti:                     []AntiKeyword{{Keyword: "Vehicle", Threshold: 2}},
+			targetKeywords:           []string{"Infantry"},
+			expectedNormalWound:      2.0 / 6.0,
+			expectedDevastatingWound: 0.0,
+		},
+		{
+			// The explicit 3+ beats the matching Anti-Infantry 5+.
+			name:                     "Explicit threshold better than Anti",
+			s:                        1,
+			t:                        10,
+			rerollType:               RerollNone,
+			devastatingWounds:        true,
+			criticalWoundThreshold:   3,
+			anti:                     []AntiKeyword{{Keyword: "Infantry", Threshold: 5}},
+			targetKeywords:           []string{"Infantry"},
+			expectedNormalWound:      0.0,
+			expectedDevastatingWound: 4.0 / 6.0,
+		},
+		{
+			// Anti-Monster 4+ and Anti-Vehicle 2+ vs a Vehicle: the best matching
+			// ability wins, and every crit is Devastating.
+			name:                     "Best matching Anti wins, with Devastating",
+			s:                        4,
+			t:                        8,
+			rerollType:               RerollNone,
+			devastatingWounds:        true,
+			criticalWoundThreshold:   6,
+			anti:                     []AntiKeyword{{Keyword: "Monster", Threshold: 4}, {Keyword: "Vehicle", Threshold: 2}},
+			targetKeywords:           []string{"Vehicle"},
+			expectedNormalWound:      0.0,
+			expectedDevastatingWound: 5.0 / 6.0,
+		},
 	}
 
 	for _, tc := range tests {
@@ -107,6 +161,9 @@ func TestCalculateWoundProbability(t *testing.T) {
 				tc.woundModifier,
 				tc.devastatingWounds,
 				tc.criticalWoundThreshold,
+				tc.anti,
+				tc.targetKeywords,
+				0,
 			)
 
 			// Assert Normal Wound
@@ -117,3 +174,152 @@ func TestCalculateWoundProbability(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateSingleWoundDistribution(t *testing.T) {
+	infantry := []AntiKeyword{{Keyword: "Infantry", Threshold: 5}}
+
+	tests := []struct {
+		name                   string
+		s                      int
+		t                      int
+		rerollType             RerollType
+		woundModifier          int
+		criticalWoundThreshold int
+		anti                   []AntiKeyword
+		targetKeywords         []string
+		failUpTo               int
+		expected               map[WoundOutcome]float64
+	}{
+		{
+			// S4 vs T4 wounds on 4+; Anti-Infantry 5+ makes a 5 critical.
+			name:                   "Anti-crit is its own outcome",
+			s:                      4,
+			t:                      4,
+			rerollType:             RerollNone,
+			criticalWoundThreshold: 6,
+			anti:                   infantry,
+			targetKeywords:         []string{"Infantry"},
+			expected: map[WoundOutcome]float64{
+				WoundFailed:       3.0 / 6.0,
+				WoundNormal:       1.0 / 6.0,
+				WoundAntiCritical: 1.0 / 6.0,
+				WoundCritical:     1.0 / 6.0,
+			},
+		},
+		{
+			// The three failed faces are rerolled: each face gains 3/36.
+			name:                   "Reroll failures spreads over every outcome",
+			s:                      4,
+			t:                      4,
+			rerollType:             RerollFail,
+			criticalWoundThreshold: 6,
+			anti:                   infantry,
+			targetKeywords:         []string{"Infantry"},
+			expected: map[WoundOutcome]float64{
+				WoundFailed:       9.0 / 36.0,
+				WoundNormal:       9.0 / 36.0,
+				WoundAntiCritical: 9.0 / 36.0,
+				WoundCritical:     9.0 / 36.0,
+			},
+		},
+		{
+			// S1 vs T10 needs a 6, but Anti 2+ makes every 2-5 a
+			// Critical Wound: only the natural 1 is rerolled.
+			name:                   "Critical Wounds below the wound target are never rerolled",
+			s:                      1,
+			t:                      10,
+			rerollType:             RerollFail,
+			criticalWoundThreshold: 6,
+			anti:                   []AntiKeyword{{Keyword: "Infantry", Threshold: 2}},
+			targetKeywords:         []string{"Infantry"},
+			expected: map[WoundOutcome]float64{
+				WoundFailed:       1.0 / 36.0,
+				WoundAntiCritical: 28.0 / 36.0,
+				WoundCritical:     7.0 / 36.0,
+			},
+		},
+		{
+			// The -1 makes S4 vs T4 need a 5; the explicit Critical Wound
+			// threshold of 4+ still succeeds on the unmodified 4.
+			name:                   "Critical threshold below the modified target",
+			s:                      4,
+			t:                      4,
+			rerollType:             RerollNone,
+			woundModifier:          -1,
+			criticalWoundThreshold: 4,
+			expected: map[WoundOutcome]float64{
+				WoundFailed:   3.0 / 6.0,
+				WoundCritical: 3.0 / 6.0,
+			},
+		}, {
+			// Faces 1-5 are all rerolled, the successful 4 and 5 included:
+			// each face gains 5/36.
+			name:                   "Reroll non-critical fishes for Critical Wounds",
+			s:                      4,
+			t:                      4,
+			rerollType:             RerollNonCritical,
+			criticalWoundThreshold: 6,
+			expected: map[WoundOutcome]float64{
+				WoundFailed:   15.0 / 36.0,
+				WoundNormal:   10.0 / 36.0,
+				WoundCritical: 11.0 / 36.0,
+			},
+		},
+		{
+			// S8 vs T4 wounds on 2+ and Anti 2+ makes every roll a
+			// Critical Wound, but 1-3 still fail.
+			name:                   "Rolls of 1-3 fail whatever the abilities",
+			s:                      8,
+			t:                      4,
+			rerollType:             RerollNone,
+			criticalWoundThreshold: 6,
+			anti:                   []AntiKeyword{{Keyword: "Infantry", Threshold: 2}},
+			targetKeywords:         []string{"Infantry"},
+			failUpTo:               3,
+			expected: map[WoundOutcome]float64{
+				WoundFailed:       3.0 / 6.0,
+				WoundAntiCritical: 2.0 / 6.0,
+				WoundCritical:     1.0 / 6.0,
+			},
+		},
+		{
+			// The failed 1-3 are rerolled: each face gains 3/36.
+			name:                   "Rolls of 1-3 fail but can be rerolled",
+			s:                      8,
+			t:                      4,
+			rerollType:             RerollFail,
+			criticalWoundThreshold: 6,
+			failUpTo:               3,
+			expected: map[WoundOutcome]float64{
+				WoundFailed:   9.0 / 36.0,
+				WoundNormal:   18.0 / 36.0,
+				WoundCritical: 9.0 / 36.0,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := CalculateSingleWoundDistribution(
+				tc.s,
+				tc.t,
+				tc.rerollType,
+				tc.woundModifier,
+				tc.criticalWoundThreshold,
+				tc.anti,
+				tc.targetKeywords,
+				tc.failUpTo,
+			)
+
+			total := 0.0
+			for outcome, p := range got {
+				assert.InDelta(t, tc.expected[outcome], p, floatTolerance, "outcome %d", outcome)
+				total += p
+			}
+			assert.InDelta(t, 1.0, total, floatTolerance, "PMF must sum to 1")
+			for outcome := range tc.expected {
+				assert.Contains(t, got, outcome)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2025 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	middleware "github.com/AnNoName1/warhammer40k10thCalc/internal/middleware"
+)
+
+// requestDTO is a JSON request body that validates itself and maps onto
+// the calculator request D.
+type requestDTO[T, D any] interface {
+	*T
+	Validate() error
+	ToDomain() (D, error)
+}
+
+// serveCalculation handles a calculation endpoint: it decodes a T from the
+// POST body, validates it, maps it to the calculator request, runs
+// calculate and writes the result as mapped by respond.
+func serveCalculation[T any, PT requestDTO[T, D], D, R, Resp any](
+	w http.ResponseWriter,
+	r *http.Request,
+	log *zap.Logger,
+	calculate func(D) (R, error),
+	respond func(R, string) Resp,
+) {
+	if r.Method != http.MethodPost {
+		SendError(w, "", "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reqID := middleware.GetRequestID(r.Context())
+
+	var dto T
+
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		msg := "Malformed JSON or invalid data types"
+		if err == io.EOF {
+			msg = "Request body cannot be empty"
+		}
+		log.Warn("JSON decode error",
+			zap.String("request_id", reqID),
+			zap.Error(err),
+		)
+		SendError(w, reqID, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := PT(&dto).Validate(); err != nil {
+		log.Warn("validation failed",
+			zap.String("request_id", reqID),
+			zap.Error(err),
+		)
+		SendError(w, reqID, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	domainReq, err := PT(&dto).ToDomain()
+	if err != nil {
+		log.Warn("domain mapping failed",
+			zap.String("request_id", reqID),
+			zap.Error(err),
+		)
+		SendError(w, reqID, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	result, err := calculate(domainReq)
+	if err != nil {
+		log.Error("calculation error",
+			zap.String("request_id", reqID),
+			zap.Error(err),
+		)
+		SendError(w, reqID, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := respond(result, reqID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error("JSON encode error",
+			zap.String("request_id", reqID),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type doubleDTO struct {
+	Value int `json:"value"`
+}
+
+func (d *doubleDTO) Validate() error {
+	if d.Value < 0 {
+		return errors.New("value cannot be negative")
+	}
+	return nil
+}
+
+func (d *doubleDTO) ToDomain() (int, error) {
+	return d.Value, nil
+}
+
+func TestServeCalculation(t *testing.T) {
+	double := func(v int) (int, error) { return 2 * v, nil }
+	respond := func(v int, reqID string) map[string]int { return map[string]int{"result": v} }
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		serveCalculation[doubleDTO](rr, req, zap.NewNop(), double, respond)
+		return rr
+	}
+
+	rr := serve(`{"value": 21}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]int
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["result"] != 42 {
+		t.Errorf("expected the doubled value 42, got %v", resp)
+	}
+
+	if rr := serve(`{"value": -1}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid request, got %d", rr.Code)
+	}
+}
@@ -21,14 +21,11 @@
 package handler
 
 import (
-	"encoding/json"
-	"io"
 	"net/http"
 
 	"go.uber.org/zap"
 
 	calculator "github.com/AnNoName1/warhammer40k10thCalc/internal/calculator"
-	middleware "github.com/AnNoName1/warhammer40k10thCalc/internal/middleware"
 	damagerequest "github.com/AnNoName1/warhammer40k10thCalc/pkg/models"
 )
 
@@ -50,66 +47,6 @@ type DamageCalculator interface {
 //	@Router			/damage/calculate [post]
 func CalculateDamageHandler(calculator DamageCalculator, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			SendError(w, "", "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		reqID := middleware.GetRequestID(r.Context())
-
-		var dto damagerequest.DamageRequestDTO
-
-		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-			msg := "Malformed JSON or invalid data types"
-			if err == io.EOF {
-				msg = "Request body cannot be empty"
-			}
-			log.Warn("JSON decode error",
-				zap.String("request_id", reqID),
-				zap.Error(err),
-			)
-			SendError(w, reqID, msg, http.StatusBadRequest)
-			return
-		}
-
-		if err := dto.Validate(); err != nil {
-			log.Warn("validation failed",
-				zap.String("request_id", reqID),
-				zap.Error(err),
-			)
-			SendError(w, reqID, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		domainReq, err := dto.ToDomain()
-		if err != nil {
-			log.Warn("domain mapping failed",
-				zap.String("request_id", reqID),
-				zap.Error(err),
-			)
-			SendError(w, reqID, err.Error(), http.StatusUnprocessableEntity)
-			return
-		}
-
-		result, err := calculator.CalculateDamageCore(domainReq)
-		if err != nil {
-			log.Error("calculation error",
-				zap.String("request_id", reqID),
-				zap.Error(err),
-			)
-			SendError(w, reqID, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		resp := damagerequest.MapResultToResponse(result, reqID)
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			log.Error("JSON encode error",
-				zap.String("request_id", reqID),
-				zap.Error(err),
-			)
-		}
+		serveCalculation[damagerequest.DamageRequestDTO](w, r, log, calculator.CalculateDamageCore, damagerequest.MapResultToResponse)
 	}
 }
@@ -258,4 +258,1085 @@ func TestCalculateDamageHandler_ValidationDeepDive(t *testing.T) {
 			t.Errorf("expected 400 for negative strength, got %d", rr.Code)
 		}
 	})
+
+	t.Run("AntiThresholdOutOfRange", func(t *testing.T) {
+		// Anti-X thresholds follow the same 2+..6+ range as critical thresholds.
+		body := `{
+			"attacker": {
+				"num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1",
+				"anti": [{ "keyword": "infantry", "threshold": 1 }]
+			},
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for anti threshold 1+, got %d", rr.Code)
+		}
+	})
+}
+
+func TestCalculateDamageHandler_MeltaRapidFireMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("NegativeMelta", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 9, "ap": 4, "d": "d6", "melta": -2 },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for negative melta, got %d", rr.Code)
+		}
+	})
+
+	t.Run("HalfRangeMapped", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 9, "ap": 4, "d": "d6", "melta": 2, "rapid_fire": 1 },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 },
+			"rules": { "half_range": true }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		got := mock.LastReq
+		if got.Attacker.MeltaX != 2 || got.Attacker.RapidFireX != 1 || !got.Settings.HalfRange {
+			t.Errorf("unexpected mapping: melta=%d rapid_fire=%d half_range=%v",
+				got.Attacker.MeltaX, got.Attacker.RapidFireX, got.Settings.HalfRange)
+		}
+	})
+}
+
+func TestCalculateDamageHandler_WeaponAbilitiesMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": {
+			"num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1",
+			"heavy": true, "lance": true, "twin_linked": true, "ignores_cover": true, "indirect_fire": true
+		},
+		"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 },
+		"rules": { "remained_stationary": true, "charged": true, "target_not_visible": true }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	a := mock.LastReq.Attacker
+	if !a.Heavy || !a.Lance || !a.TwinLinked || !a.IgnoresCover || !a.IndirectFire {
+		t.Errorf("weapon abilities not mapped: %+v", a)
+	}
+	s := mock.LastReq.Settings
+	if !s.RemainedStationary || !s.Charged || !s.TargetNotVisible {
+		t.Errorf("situational toggles not mapped: %+v", s)
+	}
+}
+
+func TestCalculateDamageHandler_AntiKeywordsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": {
+			"num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1",
+			"anti": [{ "keyword": "Infantry", "threshold": 4 }]
+		},
+		"target": {
+			"t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1,
+			"keywords": ["Infantry", "Imperium"]
+		}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	anti := mock.LastReq.Attacker.Anti
+	if len(anti) != 1 || anti[0].Keyword != "Infantry" || anti[0].Threshold != 4 {
+		t.Errorf("unexpected anti mapping: %+v", anti)
+	}
+	if len(mock.LastReq.Target.Keywords) != 2 {
+		t.Errorf("unexpected target keywords: %v", mock.LastReq.Target.Keywords)
+	}
+}
+
+func TestCalculateDamageHandler_FeelNoPainMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("MortalFnpOutOfRange", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1, "mortal_feel_no_pain": 7 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for mortal fnp 7+, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Mapped", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": {
+				"t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1,
+				"feel_no_pain": 6, "mortal_feel_no_pain": 4, "feel_no_pain_excludes_devastating": true
+			}
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		got := mock.LastReq.Target
+		if got.FeelNoPain == nil || *got.FeelNoPain != 6 ||
+			got.MortalFeelNoPain == nil || *got.MortalFeelNoPain != 4 ||
+			!got.FeelNoPainExcludesDevastating {
+			t.Errorf("unexpected FNP mapping: %+v", got)
+		}
+	})
+}
+
+func TestCalculateDamageHandler_MortalWoundsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("AmountWithoutTrigger", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1", "mortal_wounds": "d3" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for mortal_wounds without a trigger, got %d", rr.Code)
+		}
+	})
+
+	t.Run("UnknownTrigger", func(t *testing.T) {
+		body := `{
+			"attacker": {
+				"num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1",
+				"mortal_wounds": "1", "mortal_wounds_on": "any_hit"
+			},
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for unknown trigger, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Mapped", func(t *testing.T) {
+		body := `{
+			"attacker": {
+				"num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1",
+				"mortal_wounds": "d3", "mortal_wounds_on": "critical_hit", "legacy_devastating_wounds": true
+			},
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		a := mock.LastReq.Attacker
+		if a.MortalWounds != (calculator.DiceRoll{Count: 1, Sides: 3}) ||
+			a.MortalWoundsOn != calculator.MortalWoundsOnCriticalHit ||
+			!a.LegacyDevastatingWounds {
+			t.Errorf("unexpected mortal wound mapping: %+v", a)
+		}
+	})
+}
+
+func TestCalculateDamageHandler_DamageReductionMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("NegativeReduction", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "2" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 3, "model_count": 1, "damage_reduction": -1 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for negative damage_reduction, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Mapped", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "2" },
+			"target": {
+				"t": 4, "save": 3, "wounds_per_model": 3, "model_count": 1,
+				"damage_reduction": 1, "halve_damage": true, "damage_reduction_excludes_devastating": true
+			}
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		got := mock.LastReq.Target
+		if got.DamageReduction != 1 || !got.HalveDamage || !got.DamageReductionExcludesDevastating {
+			t.Errorf("unexpected damage reduction mapping: %+v", got)
+		}
+	})
+}
+
+func TestCalculateDamageHandler_WeaponsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("InvalidWeapon", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 5, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"weapons": [{ "num_models": 1, "attacks_string": "1", "bs": 1, "s": 8, "ap": 3, "d": "2" }],
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 10 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a weapon with BS 1+, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Mapped", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 5, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1", "hit_modifier": 1 },
+			"weapons": [{ "num_models": 1, "attacks_string": "d6", "bs": 3, "s": 8, "ap": 3, "d": "2", "wound_modifier": -1 }],
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 10 }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		got := mock.LastReq
+		if got.Attacker.Count != 5 || got.Attacker.HitModifier != 1 {
+			t.Errorf("unexpected attacker mapping: %+v", got.Attacker)
+		}
+		if len(got.Weapons) != 1 {
+			t.Fatalf("expected 1 extra weapon, got %d", len(got.Weapons))
+		}
+		w := got.Weapons[0]
+		if w.Count != 1 || w.Attacks != (calculator.DiceRoll{Count: 1, Sides: 6}) || w.WoundModifier != -1 {
+			t.Errorf("unexpected weapon mapping: %+v", w)
+		}
+	})
+}
+
+func TestMapResultToResponse_Weapons(t *testing.T) {
+	res := calculator.SimulationResult{
+		AverageDestroyed: 1.5,
+		Weapons: []calculator.SimulationResult{
+			{AverageDestroyed: 1.0},
+			{AverageDestroyed: 0.25},
+		},
+	}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	if len(resp.Weapons) != 2 {
+		t.Fatalf("expected 2 weapon breakdowns, got %d", len(resp.Weapons))
+	}
+	if resp.Weapons[1].Summary.AverageDestroyed != 0.25 {
+		t.Errorf("unexpected breakdown: %+v", resp.Weapons[1])
+	}
+}
+
+func TestCalculateDamageHandler_ModelGroupsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("InvalidGroup", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "groups": [{ "model_count": 4, "wounds_per_model": 1, "save": 1 }] }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a group with Save 1+, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Mapped", func(t *testing.T) {
+		// Blast, wounds_per_model and save are all satisfied by the groups.
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "d6", "bs": 3, "s": 4, "ap": 0, "d": "1", "blast": true },
+			"target": {
+				"t": 4,
+				"groups": [
+					{ "name": "Troopers", "model_count": 9, "wounds_per_model": 1, "save": 3 },
+					{ "name": "Captain", "model_count": 1, "wounds_per_model": 5, "save": 3, "invulnerable": 4, "character": true }
+				]
+			}
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		groups := mock.LastReq.Target.Groups
+		if len(groups) != 2 {
+			t.Fatalf("expected 2 groups, got %d", len(groups))
+		}
+		c := groups[1]
+		if c.Name != "Captain" || c.Count != 1 || c.WoundsPerModel != 5 || *c.Invulnerable != 4 || !c.Character {
+			t.Errorf("unexpected group mapping: %+v", c)
+		}
+	})
+}
+
+func TestMapResultToResponse_Groups(t *testing.T) {
+	res := calculator.SimulationResult{
+		Groups: []calculator.GroupResult{
+			{Name: "Troopers", DestroyedDist: map[int]float64{0: 0.5, 1: 0.5}},
+		},
+		WoundsLostDist: map[int]float64{1: 0.5, 2: 0.5},
+	}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	got := resp.Distributions.GroupsDestroyed
+	if len(got) != 1 || got[0].Name != "Troopers" || got[0].Destroyed[1] != 0.5 {
+		t.Errorf("unexpected groups_destroyed: %+v", got)
+	}
+	if resp.Distributions.WoundsLost[2] != 0.5 {
+		t.Errorf("unexpected wounds_lost: %+v", resp.Distributions.WoundsLost)
+	}
+}
+
+func TestCalculateDamageHandler_PrecisionMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	t.Run("PrecisionTargetNotCharacter", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1", "precision": true },
+			"target": {
+				"t": 4, "precision_target": "Troopers",
+				"groups": [{ "name": "Troopers", "model_count": 4, "wounds_per_model": 1, "save": 3 }]
+			}
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a non-character precision_target, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Mapped", func(t *testing.T) {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1", "precision": true },
+			"target": {
+				"t": 4, "precision_target": "Captain",
+				"groups": [
+					{ "name": "Troopers", "model_count": 4, "wounds_per_model": 1, "save": 3 },
+					{ "name": "Captain", "model_count": 1, "wounds_per_model": 5, "save": 3, "character": true }
+				]
+			}
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		got := mock.LastReq
+		if !got.Attacker.Precision || got.Target.PrecisionTarget != "Captain" {
+			t.Errorf("unexpected precision mapping: %+v %+v", got.Attacker, got.Target)
+		}
+	})
+}
+
+func TestCalculateDamageHandler_RerollStrategiesMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1", "lethal_hits": true },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 },
+		"rules": { "hit_reroll": "non_crit", "wound_reroll": "optimal" }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq.Settings
+	if got.HitReroll != calculator.RerollNonCritical || got.WoundReroll != calculator.RerollOptimal {
+		t.Errorf("unexpected reroll mapping: hit %v, wound %v", got.HitReroll, got.WoundReroll)
+	}
+}
+
+func TestCalculateDamageHandler_SingleRerollMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "d6", "bs": 3, "s": 4, "ap": 0, "d": "d3" },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 },
+		"rules": { "single_reroll": { "hit": true, "damage": true, "attacks": true } }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	want := calculator.SingleRerolls{Hit: true, Damage: true, Attacks: true}
+	if got := mock.LastReq.Settings.SingleReroll; got != want {
+		t.Errorf("unexpected single reroll mapping: got %+v, want %+v", got, want)
+	}
+}
+
+func TestCalculateDamageHandler_SingleRerollRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "With weapons",
+			body: `{
+				"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+				"weapons": [ { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1" } ],
+				"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 5 },
+				"rules": { "single_reroll": { "hit": true } }
+			}`,
+		},
+		{
+			name: "Save and damage against groups",
+			body: `{
+				"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "d3" },
+				"target": { "t": 4, "groups": [ { "name": "Troopers", "model_count": 5, "wounds_per_model": 1, "save": 3 } ] },
+				"rules": { "single_reroll": { "save": true, "damage": true } }
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCalculator{}
+			h := CalculateDamageHandler(mock, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCalculateDamageHandler_ModifierSourcesMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1",
+			"hit_modifiers": [ { "source": "Aura", "value": 1 } ], "ignore_hit_modifiers": true },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 },
+		"rules": { "hit_modifiers": [ { "source": "Stealth", "value": -1 } ], "wound_modifiers": [ { "source": "Debuff", "value": -1 } ] }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq
+	if len(got.Attacker.HitModifiers) != 1 || got.Attacker.HitModifiers[0] != (calculator.Modifier{Source: "Aura", Value: 1}) || !got.Attacker.IgnoreHitModifiers {
+		t.Errorf("unexpected attacker modifiers: %+v", got.Attacker)
+	}
+	if len(got.Settings.HitModifiers) != 1 || got.Settings.HitModifiers[0].Source != "Stealth" ||
+		len(got.Settings.WoundModifiers) != 1 || got.Settings.WoundModifiers[0].Value != -1 {
+		t.Errorf("unexpected rules modifiers: %+v", got.Settings)
+	}
+}
+
+func TestCalculateDamageHandler_UnnamedModifierSource(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 1 },
+		"rules": { "hit_modifiers": [ { "source": " ", "value": 1 } ] }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMapResultToResponse_EffectiveModifiers(t *testing.T) {
+	res := calculator.SimulationResult{EffectiveHitModifier: 1, EffectiveWoundModifier: -1}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	if resp.Summary.EffectiveHitModifier != 1 || resp.Summary.EffectiveWoundModifier != -1 {
+		t.Errorf("unexpected effective modifiers: %+v", resp.Summary)
+	}
+}
+
+func TestCalculateDamageHandler_TargetAbilitiesMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 5, "ap": 1, "d": "1" },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5,
+			"stealth": true, "minus_one_to_wound_if_stronger": true, "ap_reduction": 1, "wound_rolls_fail_up_to": 3 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq.Target
+	if !got.Stealth || !got.MinusOneToWoundIfStronger || got.APReduction != 1 || got.WoundRollsFailUpTo != 3 {
+		t.Errorf("unexpected target abilities: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_InvalidWoundRollsFailUpTo(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 5, "ap": 1, "d": "1" },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5, "wound_rolls_fail_up_to": 6 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCalculateDamageHandler_DamageOptionsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 5, "ap": 1, "d": "d6",
+			"damage_options": { "reroll": "below", "reroll_below": 3, "best_of": 2, "minimum": 2 } },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	want := calculator.DiceOptions{Reroll: calculator.DiceRerollBelow, RerollBelow: 3, BestOf: 2, Minimum: 2}
+	if got := mock.LastReq.Attacker.DamageOptions; got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestCalculateDamageHandler_InvalidDamageOptions(t *testing.T) {
+	tests := map[string]string{
+		"reroll_below without below": `{ "reroll": "below_average", "reroll_below": 3 }`,
+		"unknown reroll":             `{ "reroll": "twice" }`,
+		"best_of out of range":       `{ "best_of": 7 }`,
+		"negative minimum":           `{ "minimum": -1 }`,
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock := &MockCalculator{}
+			h := CalculateDamageHandler(mock, zap.NewNop())
+
+			body := `{
+				"attacker": { "num_models": 1, "attacks_string": "1", "bs": 3, "s": 5, "ap": 1, "d": "d6",
+					"damage_options": ` + opts + ` },
+				"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+			}`
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCalculateDamageHandler_AttacksOptionsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 3, "attacks_string": "d6", "bs": 3, "s": 5, "ap": 1, "d": "1",
+			"attacks_options": { "reroll": "below_average", "minimum": 3 }, "attacks_per_unit": true },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq.Attacker
+	want := calculator.DiceOptions{Reroll: calculator.DiceRerollBelowAverage, Minimum: 3}
+	if got.AttacksOptions != want || !got.AttacksRolledPerUnit {
+		t.Errorf("expected %+v rolled per unit, got %+v (per unit %v)", want, got.AttacksOptions, got.AttacksRolledPerUnit)
+	}
+}
+
+func TestCalculateDamageHandler_InvalidAttacksOptions(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "d6", "bs": 3, "s": 5, "ap": 1, "d": "1",
+			"attacks_options": { "reroll": "below" } },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCalculateDamageHandler_CriticalHitEffectsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 5, "ap": 1, "d": "1",
+			"sustained_hits_dice": "d3", "critical_hit_strength": 8, "critical_hit_ap_bonus": 1 },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq.Attacker
+	if got.SustainedHitsDice != (calculator.DiceRoll{Count: 1, Sides: 3}) ||
+		got.CriticalHitStrength != 8 || got.CriticalHitAPBonus != 1 {
+		t.Errorf("unexpected critical hit effects: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_InvalidSustainedHitsDice(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 5, "ap": 1, "d": "1",
+			"sustained_hits_dice": "lots" },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCalculateDamageHandler_CriticalWoundEffectsMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 5, "ap": 1, "d": "1",
+			"critical_wound_ap_bonus": 2, "critical_wound_damage_bonus": 1 },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq.Attacker
+	if got.CriticalWoundAPBonus != 2 || got.CriticalWoundDamageBonus != 1 {
+		t.Errorf("unexpected critical wound effects: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_InvalidCriticalWoundEffects(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 5, "ap": 1, "d": "1",
+			"critical_wound_damage_bonus": -1 },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCalculateDamageHandler_HazardousMapping(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 8, "ap": 3, "d": "2",
+			"hazardous": true, "wounds_per_model": 4, "keywords": ["CHARACTER"] },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := mock.LastReq.Attacker
+	if !got.Hazardous || got.WoundsPerModel != 4 || len(got.Keywords) != 1 || got.Keywords[0] != "CHARACTER" {
+		t.Errorf("unexpected hazardous mapping: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_InvalidAttackerWoundsPerModel(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	body := `{
+		"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 8, "ap": 3, "d": "2",
+			"hazardous": true, "wounds_per_model": -1 },
+		"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 }
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMapResultToResponse_SelfDestroyed(t *testing.T) {
+	res := calculator.SimulationResult{SelfDestroyedDist: map[int]float64{0: 5.0 / 6.0, 1: 1.0 / 6.0}}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	if got := resp.Distributions.SelfDestroyed; got[1] != 1.0/6.0 {
+		t.Errorf("unexpected self_destroyed: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_FiringMode(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	send := func(rules string) *httptest.ResponseRecorder {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 5 },
+			"rules": ` + rules + `
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Overwatch", func(t *testing.T) {
+		rr := send(`{ "firing_mode": "overwatch" }`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if mock.LastReq.Settings.FiringMode != calculator.FiringModeOverwatch {
+			t.Errorf("expected overwatch, got %v", mock.LastReq.Settings.FiringMode)
+		}
+	})
+
+	t.Run("DefaultsToNormal", func(t *testing.T) {
+		rr := send(`{}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if mock.LastReq.Settings.FiringMode != calculator.FiringModeNormal {
+			t.Errorf("expected normal, got %v", mock.LastReq.Settings.FiringMode)
+		}
+	})
+
+	t.Run("UnknownMode", func(t *testing.T) {
+		if rr := send(`{ "firing_mode": "snap" }`); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestMapResultToResponse_Stats(t *testing.T) {
+	res := calculator.SimulationResult{Stats: calculator.DistributionStats{
+		Damage: calculator.DistStats{Mean: 2.5, Median: 2, P90: 4, AtLeast: map[int]float64{0: 1, 1: 0.8}},
+	}}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	got := resp.Summary.Stats.Damage
+	if got.Mean != 2.5 || got.Median != 2 || got.P90 != 4 || got.AtLeast[1] != 0.8 {
+		t.Errorf("unexpected damage stats: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_Thresholds(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	send := func(thresholds string) *httptest.ResponseRecorder {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 1, "model_count": 5 },
+			"thresholds": ` + thresholds + `
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Mapping", func(t *testing.T) {
+		rr := send(`{ "models_destroyed": [1, 5], "damage": [3] }`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		got := mock.LastReq.Thresholds
+		if len(got.Destroyed) != 2 || got.Destroyed[1] != 5 || len(got.Damage) != 1 || got.Damage[0] != 3 {
+			t.Errorf("unexpected thresholds: %+v", got)
+		}
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		if rr := send(`{ "damage": [-1] }`); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestMapResultToResponse_Thresholds(t *testing.T) {
+	res := calculator.SimulationResult{
+		WipeProb:          0.2,
+		DestroyedAtLeast:  map[int]float64{3: 0.4},
+		DamageAtLeast:     map[int]float64{6: 0.5},
+		DestroyedTailMass: 1e-9,
+	}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	got := resp.Summary
+	if got.WipeProbability != 0.2 || got.DestroyedAtLeast[3] != 0.4 || got.DamageAtLeast[6] != 0.5 || got.DestroyedTailMass != 1e-9 {
+		t.Errorf("unexpected threshold summary: %+v", got)
+	}
+}
+
+func TestCalculateDamageHandler_WoundsRemaining(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	send := func(remaining string) *httptest.ResponseRecorder {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5, "wounds_remaining": ` + remaining + ` }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Mapping", func(t *testing.T) {
+		rr := send("7")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if got := mock.LastReq.Target.WoundsRemaining; got == nil || *got != 7 {
+			t.Errorf("unexpected wounds remaining: %v", got)
+		}
+	})
+
+	for _, remaining := range []string{"0", "11"} {
+		t.Run("Invalid "+remaining, func(t *testing.T) {
+			if rr := send(remaining); rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCalculateDamageHandler_WoundsRemainingDist(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	send := func(remaining string) *httptest.ResponseRecorder {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5, ` + remaining + ` }
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Mapping", func(t *testing.T) {
+		rr := send(`"wounds_remaining_dist": { "0": 0.25, "7": 0.75 }`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if got := mock.LastReq.Target.WoundsRemainingDist; got[0] != 0.25 || got[7] != 0.75 {
+			t.Errorf("unexpected wounds remaining: %v", got)
+		}
+	})
+
+	for name, remaining := range map[string]string{
+		"Both":        `"wounds_remaining": 7, "wounds_remaining_dist": { "7": 1 }`,
+		"Exceeds":     `"wounds_remaining_dist": { "11": 1 }`,
+		"Negative":    `"wounds_remaining_dist": { "-1": 0.5, "7": 0.5 }`,
+		"Probability": `"wounds_remaining_dist": { "6": -0.5, "7": 1.5 }`,
+		"Sum":         `"wounds_remaining_dist": { "6": 0.5, "7": 0.4 }`,
+		"Empty":       `"wounds_remaining_dist": {}`,
+	} {
+		t.Run("Invalid "+name, func(t *testing.T) {
+			if rr := send(remaining); rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestMapResultToResponse_RemainingWounds(t *testing.T) {
+	res := calculator.SimulationResult{
+		RemainingWoundsDist: map[int]float64{3: 0.5, 4: 0.5},
+		WoundedModelDist:    map[int]float64{1: 0.5, 2: 0.5},
+	}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	if resp.Distributions.RemainingWounds[3] != 0.5 || resp.Distributions.WoundedModel[1] != 0.5 {
+		t.Errorf("unexpected remaining wounds: %+v", resp.Distributions)
+	}
+}
+
+func TestCalculateDamageHandler_Points(t *testing.T) {
+	mock := &MockCalculator{}
+	h := CalculateDamageHandler(mock, zap.NewNop())
+
+	send := func(points string) *httptest.ResponseRecorder {
+		body := `{
+			"attacker": { "num_models": 1, "attacks_string": "2", "bs": 3, "s": 4, "ap": 0, "d": "1" },
+			"target": { "t": 4, "save": 3, "wounds_per_model": 2, "model_count": 5 },
+			"points": ` + points + `
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Mapping", func(t *testing.T) {
+		rr := send(`{ "attacker": 120, "target_per_model": 18 }`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		want := calculator.UnitPoints{Attacker: 120, TargetPerModel: 18}
+		if mock.LastReq.Points != want {
+			t.Errorf("expected %+v, got %+v", want, mock.LastReq.Points)
+		}
+	})
+
+	for _, points := range []string{`{ "attacker": -1 }`, `{ "target_per_model": -5 }`} {
+		t.Run("Invalid "+points, func(t *testing.T) {
+			if rr := send(points); rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestMapResultToResponse_Points(t *testing.T) {
+	res := calculator.SimulationResult{
+		PointsDestroyedDist:     map[int]float64{0: 0.5, 18: 0.5},
+		ExpectedPointsDestroyed: 9,
+		PointsPer100:            7.5,
+	}
+
+	resp := damagerequest.MapResultToResponse(res, "id")
+
+	if resp.Distributions.PointsDestroyed[18] != 0.5 || resp.Summary.ExpectedPointsDestroyed != 9 || resp.Summary.PointsPer100 != 7.5 {
+		t.Errorf("unexpected points results: %+v %+v", resp.Summary, resp.Distributions)
+	}
 }
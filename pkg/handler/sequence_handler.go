@@ -0,0 +1,52 @@
+// Copyright (c) 2025 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	calculator "github.com/AnNoName1/warhammer40k10thCalc/internal/calculator"
+	damagerequest "github.com/AnNoName1/warhammer40k10thCalc/pkg/models"
+)
+
+type SequenceCalculator interface {
+	CalculateSequence(calculator.SequenceRequest) (calculator.SequenceResult, error)
+}
+
+// CalculateSequenceHandler is the HTTP handler for several units attacking the same target in turn.
+//
+//	@Summary		Calculate Sequence
+//	@Description	Resolves an ordered list of unit activations against one target, each starting from the wounds the previous ones left. Stops once the target is certain to be wiped.
+//	@Tags			damage
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Request-ID	header		string								false	"Request UUID"
+//	@Param			request			body		damagerequest.SequenceRequestDTO	true	"Activations and target"
+//	@Success		200				{object}	damagerequest.SequenceResponseDTO
+//	@Failure		400				{object}	map[string]string	"Invalid input payload"
+//	@Router			/damage/sequence [post]
+func CalculateSequenceHandler(calculator SequenceCalculator, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serveCalculation[damagerequest.SequenceRequestDTO](w, r, log, calculator.CalculateSequence, damagerequest.MapSequenceToResponse)
+	}
+}
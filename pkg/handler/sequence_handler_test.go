@@ -0,0 +1,170 @@
+// Copyright (c) 2025 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	calculator "github.com/AnNoName1/warhammer40k10thCalc/internal/calculator"
+	damagerequest "github.com/AnNoName1/warhammer40k10thCalc/pkg/models"
+)
+
+type MockSequenceCalculator struct {
+	ShouldFail bool
+	LastReq    calculator.SequenceRequest
+}
+
+// CalculateSequence implements [SequenceCalculator].
+func (m *MockSequenceCalculator) CalculateSequence(
+	req calculator.SequenceRequest,
+) (calculator.SequenceResult, error) {
+
+	m.LastReq = req
+
+	if m.ShouldFail {
+		return calculator.SequenceResult{}, errors.New("core failure")
+	}
+
+	return calculator.SequenceResult{
+		Steps: []calculator.SequenceStep{
+			{DestroyedDist: map[int]float64{1: 1.0}, CumulativeDestroyedDist: map[int]float64{1: 1.0}},
+			{DestroyedDist: map[int]float64{2: 1.0}, CumulativeDestroyedDist: map[int]float64{3: 1.0}},
+		},
+		DestroyedDist: map[int]float64{3: 1.0},
+	}, nil
+}
+
+func validSequenceJSON() string {
+	return `{
+		"activations": [
+			{
+				"attacker": {"num_models": 1, "attacks_string": "1", "bs": 4, "s": 4, "ap": 0, "d": "1"},
+				"rules": {}
+			},
+			{
+				"attacker": {"num_models": 5, "attacks_string": "2", "bs": 3, "s": 5, "ap": 1, "d": "1"},
+				"rules": {"hit_reroll": "ones"}
+			}
+		],
+		"target": {
+			"t": 4,
+			"save": 3,
+			"wounds_per_model": 2,
+			"model_count": 5
+		}
+	}`
+}
+
+func TestCalculateSequenceHandler_Success(t *testing.T) {
+	mock := &MockSequenceCalculator{}
+	h := CalculateSequenceHandler(mock, zap.NewNop())
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/damage/sequence",
+		bytes.NewBufferString(validSequenceJSON()),
+	)
+	rr := httptest.NewRecorder()
+
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if len(mock.LastReq.Activations) != 2 {
+		t.Fatalf("expected 2 activations, got %d", len(mock.LastReq.Activations))
+	}
+	second := mock.LastReq.Activations[1]
+	if second.Attacker.Count != 5 || second.Settings.HitReroll != calculator.RerollOnes {
+		t.Errorf("second activation not mapped: %+v", second)
+	}
+	if *mock.LastReq.Target.Count != 5 {
+		t.Errorf("expected target count 5, got %d", *mock.LastReq.Target.Count)
+	}
+
+	var resp damagerequest.SequenceResponseDTO
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Steps) != 2 || resp.Steps[1].CumulativeDestroyed[3] != 1.0 || resp.Destroyed[3] != 1.0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestCalculateSequenceHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		shouldFail bool
+		expected   int
+	}{
+		{"Core failure", validSequenceJSON(), true, http.StatusBadRequest},
+		{"Malformed JSON", `{ "activations": [`, false, http.StatusBadRequest},
+		{"No activations", `{"activations": [], "target": {"t": 4, "save": 3, "wounds_per_model": 1}}`, false, http.StatusBadRequest},
+		{"Invalid activation", `{
+			"activations": [{"attacker": {"num_models": 1, "attacks_string": "1", "bs": 4, "s": 0, "d": "1"}}],
+			"target": {"t": 4, "save": 3, "wounds_per_model": 1}
+		}`, false, http.StatusBadRequest},
+		{"Single reroll", `{
+			"activations": [{"attacker": {"num_models": 1, "attacks_string": "1", "bs": 4, "s": 4, "d": "1"}, "rules": {"single_reroll": {"hit": true}}}],
+			"target": {"t": 4, "save": 3, "wounds_per_model": 1}
+		}`, false, http.StatusBadRequest},
+		{"Unparsable dice", `{
+			"activations": [{"attacker": {"num_models": 1, "attacks_string": "D7x", "bs": 4, "s": 4, "d": "1"}}],
+			"target": {"t": 4, "save": 3, "wounds_per_model": 1}
+		}`, false, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CalculateSequenceHandler(&MockSequenceCalculator{ShouldFail: tt.shouldFail}, zap.NewNop())
+			req := httptest.NewRequest(http.MethodPost, "/damage/sequence", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expected {
+				t.Errorf("expected %d, got %d: %s", tt.expected, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCalculateSequenceHandler_MethodNotAllowed(t *testing.T) {
+	h := CalculateSequenceHandler(&MockSequenceCalculator{}, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/damage/sequence", nil)
+	rr := httptest.NewRecorder()
+
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+}
@@ -23,6 +23,7 @@ package damagerequest
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -33,8 +34,28 @@ import (
 // DamageRequestDTO is the structured JSON body
 type DamageRequestDTO struct {
 	Attacker AttackerDTO `json:"attacker"`
-	Target   TargetDTO   `json:"target"`
-	Rules    RulesDTO    `json:"rules"`
+	// Weapons are further profiles fired by the same unit, resolved after attacker.
+	Weapons []AttackerDTO `json:"weapons,omitempty"`
+	Target  TargetDTO     `json:"target"`
+	Rules   RulesDTO      `json:"rules"`
+	// Thresholds are the results whose odds the summary reports.
+	Thresholds ThresholdsDTO `json:"thresholds"`
+	// Points prices the attacking unit and the target's models.
+	Points PointsDTO `json:"points"`
+}
+
+// ThresholdsDTO asks for the probability of destroying at least k models
+// and of dealing at least d damage, for each listed k and d.
+type ThresholdsDTO struct {
+	Destroyed []int `json:"models_destroyed,omitempty"`
+	Damage    []int `json:"damage,omitempty"`
+}
+
+// PointsDTO holds the cost of the whole attacking unit and of one target
+// model. Either may be omitted.
+type PointsDTO struct {
+	Attacker       int `json:"attacker,omitempty"`
+	TargetPerModel int `json:"target_per_model,omitempty"`
 }
 
 // AttackerDTO includes weapon keywords and roll modifiers.
@@ -53,6 +74,82 @@ type AttackerDTO struct {
 	Torrent           bool `json:"torrent,omitempty"`
 	HitModifier       int  `json:"hit_modifier,omitempty"`
 	WoundModifier     int  `json:"wound_modifier,omitempty"`
+	// HitModifiers and WoundModifiers are named modifier sources of this
+	// profile. With rules.hit_modifiers/wound_modifiers and the weapon
+	// abilities, they are summed and capped at +1/-1.
+	HitModifiers   []ModifierDTO `json:"hit_modifiers,omitempty"`
+	WoundModifiers []ModifierDTO `json:"wound_modifiers,omitempty"`
+	// IgnoreHitModifiers/IgnoreWoundModifiers: the profile may ignore any or all modifiers to those rolls.
+	IgnoreHitModifiers   bool `json:"ignore_hit_modifiers,omitempty"`
+	IgnoreWoundModifiers bool `json:"ignore_wound_modifiers,omitempty"`
+	// Anti lists [ANTI-KEYWORD X+] abilities, e.g. Anti-Infantry 4+.
+	Anti []AntiDTO `json:"anti,omitempty"`
+	// Melta is X of [MELTA X]: +X damage per attack within half range.
+	Melta int `json:"melta,omitempty"`
+	// RapidFire is X of [RAPID FIRE X]: +X attacks per model within half range.
+	RapidFire int `json:"rapid_fire,omitempty"`
+	// Heavy: +1 to hit if the unit remained stationary.
+	Heavy bool `json:"heavy,omitempty"`
+	// Lance: +1 to wound if the unit charged.
+	Lance bool `json:"lance,omitempty"`
+	// TwinLinked: re-roll wound rolls.
+	TwinLinked bool `json:"twin_linked,omitempty"`
+	// IgnoresCover: the target never has the Benefit of Cover.
+	IgnoresCover bool `json:"ignores_cover,omitempty"`
+	// IndirectFire: -1 to hit and the target has cover when it is not visible.
+	IndirectFire bool `json:"indirect_fire,omitempty"`
+	// MortalWounds inflicted per critical result of mortal_wounds_on, e.g. "1" or "d3".
+	MortalWounds   string                        `json:"mortal_wounds,omitempty"`
+	MortalWoundsOn calculator.MortalWoundTrigger `json:"mortal_wounds_on,omitempty"`
+	// LegacyDevastatingWounds: a critical wound inflicts mortal wounds equal to Damage instead.
+	LegacyDevastatingWounds bool `json:"legacy_devastating_wounds,omitempty"`
+	// Precision: attacks are allocated to the target's precision_target Character group first.
+	Precision bool `json:"precision,omitempty"`
+	// DamageOptions change how each roll of d is made, e.g. "minimum 3" or "roll twice, keep the highest".
+	DamageOptions DiceOptionsDTO `json:"damage_options"`
+	// AttacksOptions change how each roll of attacks_string is made, e.g. "count results below 3 as 3".
+	AttacksOptions DiceOptionsDTO `json:"attacks_options"`
+	// AttacksPerUnit rolls attacks_string once for the whole unit instead of once per model.
+	AttacksPerUnit bool `json:"attacks_per_unit,omitempty"`
+	// SustainedHitsDice is a variable Sustained Hits value, e.g. "d3", added to sustained_hits.
+	SustainedHitsDice string `json:"sustained_hits_dice,omitempty"`
+	// CriticalHitStrength is the Strength a Critical Hit rolls to wound with (0 keeps s).
+	CriticalHitStrength int `json:"critical_hit_strength,omitempty"`
+	// CriticalHitAPBonus improves the AP of a Critical Hit, e.g. 1 turns AP -1 into AP -2.
+	CriticalHitAPBonus int `json:"critical_hit_ap_bonus,omitempty"`
+	// CriticalWoundAPBonus improves the AP of an attack that scores a Critical Wound.
+	CriticalWoundAPBonus int `json:"critical_wound_ap_bonus,omitempty"`
+	// CriticalWoundDamageBonus adds to the Damage of an attack that scores a Critical Wound.
+	CriticalWoundDamageBonus int `json:"critical_wound_damage_bonus,omitempty"`
+	// Hazardous: each model takes a Hazardous test after firing.
+	Hazardous bool `json:"hazardous,omitempty"`
+	// WoundsPerModel and Keywords describe the attacking models for Hazardous tests,
+	// e.g. ["CHARACTER"] for a model that suffers mortal wounds instead of being destroyed.
+	WoundsPerModel int      `json:"wounds_per_model,omitempty"`
+	Keywords       []string `json:"keywords,omitempty"`
+}
+
+// DiceOptionsDTO describes rerolls, best-of rolls and a minimum for a dice characteristic.
+type DiceOptionsDTO struct {
+	// Reroll is "below_average" (rolls below the dice average), "below" (results below reroll_below) or "single" (one roll per volley).
+	Reroll      calculator.DiceReroll `json:"reroll,omitempty"`
+	RerollBelow int                   `json:"reroll_below,omitempty"`
+	// BestOf rolls the dice this many times and keeps the highest result.
+	BestOf int `json:"best_of,omitempty"`
+	// Minimum is the lowest result of the roll, before other modifiers.
+	Minimum int `json:"minimum,omitempty"`
+}
+
+// ModifierDTO is one named source of a hit or wound roll modifier.
+type ModifierDTO struct {
+	Source string `json:"source"`
+	Value  int    `json:"value"`
+}
+
+// AntiDTO is a single [ANTI-KEYWORD X+] ability.
+type AntiDTO struct {
+	Keyword   string `json:"keyword"`
+	Threshold int    `json:"threshold"`
 }
 
 // TargetDTO includes defensive layers and resilience rules.
@@ -64,6 +161,49 @@ type TargetDTO struct {
 	Cover          bool `json:"cover"`
 	Invulnerable   *int `json:"invulnerable,omitempty"`
 	FeelNoPain     *int `json:"feel_no_pain,omitempty"` // FNP (e.g., 6)
+	// MortalFeelNoPain is an FNP usable only against mortal wounds.
+	MortalFeelNoPain *int `json:"mortal_feel_no_pain,omitempty"`
+	// FeelNoPainExcludesDevastating stops feel_no_pain from applying to devastating wound damage.
+	FeelNoPainExcludesDevastating bool `json:"feel_no_pain_excludes_devastating,omitempty"`
+	// DamageReduction is subtracted from each attack's Damage (min 1), e.g. 1 for "-1 Damage".
+	DamageReduction int `json:"damage_reduction,omitempty"`
+	// HalveDamage halves each attack's Damage, rounding up, before damage_reduction.
+	HalveDamage bool `json:"halve_damage,omitempty"`
+	// DamageReductionExcludesDevastating stops damage_reduction and halve_damage from applying to devastating wounds.
+	DamageReductionExcludesDevastating bool `json:"damage_reduction_excludes_devastating,omitempty"`
+	// Keywords are matched against the attacker's Anti abilities.
+	Keywords []string `json:"keywords,omitempty"`
+	// Stealth: -1 to hit rolls of attacks against the target.
+	Stealth bool `json:"stealth,omitempty"`
+	// MinusOneToWoundIfStronger: -1 to wound rolls of attacks with Strength greater than t.
+	MinusOneToWoundIfStronger bool `json:"minus_one_to_wound_if_stronger,omitempty"`
+	// APReduction worsens each attack's AP (min 0), e.g. 1 for Armour of Contempt.
+	APReduction int `json:"ap_reduction,omitempty"`
+	// WoundRollsFailUpTo: unmodified wound rolls up to this value always fail, e.g. 3 for Transhuman.
+	WoundRollsFailUpTo int `json:"wound_rolls_fail_up_to,omitempty"`
+	// Groups describes a unit of mixed models. When set, it replaces
+	// model_count, wounds_per_model, save, invulnerable and feel_no_pain.
+	Groups []ModelGroupDTO `json:"groups,omitempty"`
+	// PrecisionTarget names the character group precision attacks are allocated to; empty is the first one.
+	PrecisionTarget string `json:"precision_target,omitempty"`
+	// WoundsRemaining is the wounds the unit has left before the attack, e.g. from an
+	// earlier remaining_wounds result. Omitted for an undamaged unit.
+	WoundsRemaining *int `json:"wounds_remaining,omitempty"`
+	// WoundsRemainingDist replaces wounds_remaining with a distribution of the wounds the
+	// unit has left, shaped like a remaining_wounds result: 0 is a unit already destroyed.
+	WoundsRemainingDist map[int]float64 `json:"wounds_remaining_dist,omitempty"`
+}
+
+// ModelGroupDTO is a set of identical models within the target unit.
+type ModelGroupDTO struct {
+	Name           string `json:"name,omitempty"`
+	ModelCount     int    `json:"model_count"`
+	WoundsPerModel int    `json:"wounds_per_model"`
+	Save           int    `json:"save"`
+	Invulnerable   *int   `json:"invulnerable,omitempty"`
+	FeelNoPain     *int   `json:"feel_no_pain,omitempty"`
+	// Character groups are allocated attacks after every other model.
+	Character bool `json:"character,omitempty"`
 }
 
 // RulesDTO handles rerolls, global modifiers, and critical thresholds.
@@ -72,18 +212,63 @@ type RulesDTO struct {
 	WoundReroll  calculator.RerollType `json:"wound_reroll,omitempty"`
 	SaveReroll   calculator.RerollType `json:"save_reroll,omitempty"`
 	SaveModifier int                   `json:"save_modifier,omitempty"`
+	// HitModifiers and WoundModifiers are named sources shared by every
+	// profile, e.g. auras or the target's Stealth.
+	HitModifiers   []ModifierDTO `json:"hit_modifiers,omitempty"`
+	WoundModifiers []ModifierDTO `json:"wound_modifiers,omitempty"`
 	// Thresholds allow for rules like "Critical hits on a 5+"
 	CriticalHitThreshold   int `json:"critical_hit_threshold,omitempty"`
 	CriticalWoundThreshold int `json:"critical_wound_threshold,omitempty"`
+	// HalfRange activates Melta and Rapid Fire.
+	HalfRange bool `json:"half_range,omitempty"`
+	// RemainedStationary activates Heavy.
+	RemainedStationary bool `json:"remained_stationary,omitempty"`
+	// Charged activates Lance.
+	Charged bool `json:"charged,omitempty"`
+	// TargetNotVisible activates the Indirect Fire penalties.
+	TargetNotVisible bool `json:"target_not_visible,omitempty"`
+	// FiringMode is "normal" or "overwatch": in Overwatch only critical hits hit, unless the weapon has Torrent.
+	FiringMode calculator.FiringMode `json:"firing_mode,omitempty"`
+	// SingleReroll allows one die to be rerolled per stage, e.g. a
+	// Command Re-roll. It cannot be used with weapons.
+	SingleReroll SingleRerollDTO `json:"single_reroll"`
+}
+
+// SingleRerollDTO selects the stages in which a single die may be rerolled.
+type SingleRerollDTO struct {
+	Hit     bool `json:"hit,omitempty"`
+	Wound   bool `json:"wound,omitempty"`
+	Save    bool `json:"save,omitempty"`
+	Damage  bool `json:"damage,omitempty"`
+	Attacks bool `json:"attacks,omitempty"`
 }
 
 func (req *DamageRequestDTO) Validate() error {
+	if err := validateAttacker(&req.Attacker, &req.Target); err != nil {
+		return err
+	}
+	for i := range req.Weapons {
+		if err := validateAttacker(&req.Weapons[i], &req.Target); err != nil {
+			return fmt.Errorf("weapons[%d]: %w", i, err)
+		}
+	}
 	if err := validateExistence(req); err != nil {
 		return err
 	}
 	if err := validateGameLegalRules(req); err != nil {
 		return err
 	}
+	for i := range req.Target.Groups {
+		if err := validateModelGroup(&req.Target.Groups[i]); err != nil {
+			return fmt.Errorf("target.groups[%d]: %w", i, err)
+		}
+	}
+	if err := validatePrecisionTarget(&req.Target); err != nil {
+		return err
+	}
+	if err := validateWoundsRemaining(&req.Target); err != nil {
+		return err
+	}
 
 	if req.Target.Invulnerable != nil {
 		if *req.Target.Invulnerable < 2 || *req.Target.Invulnerable > 6 {
@@ -97,12 +282,32 @@ func (req *DamageRequestDTO) Validate() error {
 		}
 	}
 
+	if req.Target.MortalFeelNoPain != nil {
+		if *req.Target.MortalFeelNoPain < 2 || *req.Target.MortalFeelNoPain > 6 {
+			return errors.New("mortal fnp must be between 2+ and 6+")
+		}
+	}
+
 	if req.Target.ModelCount != nil {
 		if *req.Target.ModelCount <= 0 {
 			return errors.New("target.model_count must be positive")
 		}
 	}
 
+	if err := validateModifiers(req.Rules.HitModifiers, req.Rules.WoundModifiers); err != nil {
+		return fmt.Errorf("rules: %w", err)
+	}
+
+	for _, k := range append(append([]int(nil), req.Thresholds.Destroyed...), req.Thresholds.Damage...) {
+		if k < 0 {
+			return errors.New("thresholds cannot be negative")
+		}
+	}
+
+	if req.Points.Attacker < 0 || req.Points.TargetPerModel < 0 {
+		return errors.New("points cannot be negative")
+	}
+
 	if req.Rules.CriticalHitThreshold != 0 && (req.Rules.CriticalHitThreshold < 2 || req.Rules.CriticalHitThreshold > 6) {
 		return errors.New("critical hit threshold must be between 2 and 6")
 	}
@@ -110,11 +315,65 @@ func (req *DamageRequestDTO) Validate() error {
 		return errors.New("critical wound threshold must be between 2 and 6")
 	}
 
-	if req.Attacker.Blast {
-		if req.Target.ModelCount == nil {
+	return nil
+}
+
+// validateAttacker checks a single weapon profile: the attacker or one of
+// the extra weapons.
+func validateAttacker(a *AttackerDTO, target *TargetDTO) error {
+	if a.NumModels <= 0 {
+		return errors.New("attacker.num_models must be positive")
+	}
+	if a.S <= 0 {
+		return errors.New("strength and toughness must be positive")
+	}
+	if a.Melta < 0 || a.RapidFire < 0 {
+		return errors.New("melta and rapid_fire cannot be negative")
+	}
+
+	// BS 1+ is impossible (rolls of 1 always fail). BS 6+ is the worst possible.
+	if !a.Torrent && (a.BS < 2 || a.BS > 6) {
+		return errors.New("bs must be between 2 and 6 (unless Torrent)")
+	}
+
+	for _, anti := range a.Anti {
+		if strings.TrimSpace(anti.Keyword) == "" {
+			return errors.New("anti keyword cannot be empty")
+		}
+		if anti.Threshold < 2 || anti.Threshold > 6 {
+			return errors.New("anti threshold must be between 2 and 6")
+		}
+	}
+
+	if err := validateModifiers(a.HitModifiers, a.WoundModifiers); err != nil {
+		return err
+	}
+
+	if err := validateDiceOptions(a.DamageOptions); err != nil {
+		return fmt.Errorf("damage_options: %w", err)
+	}
+	if err := validateDiceOptions(a.AttacksOptions); err != nil {
+		return fmt.Errorf("attacks_options: %w", err)
+	}
+	if a.SustainedHits < 0 || a.CriticalHitStrength < 0 || a.CriticalHitAPBonus < 0 {
+		return errors.New("sustained_hits, critical_hit_strength and critical_hit_ap_bonus cannot be negative")
+	}
+	if a.CriticalWoundAPBonus < 0 || a.CriticalWoundDamageBonus < 0 {
+		return errors.New("critical_wound_ap_bonus and critical_wound_damage_bonus cannot be negative")
+	}
+	if a.WoundsPerModel < 0 {
+		return errors.New("attacker wounds_per_model cannot be negative")
+	}
+
+	if (a.MortalWoundsOn == calculator.MortalWoundsNever) != (a.MortalWounds == "") {
+		return errors.New("mortal_wounds and mortal_wounds_on must be set together")
+	}
+
+	if a.Blast && len(target.Groups) == 0 {
+		if target.ModelCount == nil {
 			return errors.New("target.model_count is required for Blast weapons")
 		}
-		if *req.Target.ModelCount <= 0 {
+		if *target.ModelCount <= 0 {
 			return errors.New("target.model_count must be positive for Blast weapons")
 		}
 	}
@@ -123,31 +382,155 @@ func (req *DamageRequestDTO) Validate() error {
 }
 
 func validateExistence(req *DamageRequestDTO) error {
-	if req.Attacker.NumModels <= 0 {
-		return errors.New("attacker.num_models must be positive")
-	}
-	if req.Attacker.S <= 0 || req.Target.T <= 0 {
+	if req.Target.T <= 0 {
 		return errors.New("strength and toughness must be positive")
 	}
-	if req.Target.WoundsPerModel <= 0 {
+	if len(req.Target.Groups) == 0 && req.Target.WoundsPerModel <= 0 {
 		return errors.New("target.wounds_per_model must be positive")
 	}
+	if req.Target.DamageReduction < 0 {
+		return errors.New("target.damage_reduction cannot be negative")
+	}
+	if req.Target.APReduction < 0 {
+		return errors.New("target.ap_reduction cannot be negative")
+	}
+	if req.Target.WoundRollsFailUpTo < 0 || req.Target.WoundRollsFailUpTo > 5 {
+		return errors.New("target.wound_rolls_fail_up_to must be between 0 and 5")
+	}
 	return nil
 }
 
 // validateGameLegalRules rejects values that are impossible under core dice
 // mechanics, regardless of what the caller sends.
 func validateGameLegalRules(req *DamageRequestDTO) error {
-	// BS 1+ is impossible (rolls of 1 always fail). BS 6+ is the worst possible.
-	if !req.Attacker.Torrent && (req.Attacker.BS < 2 || req.Attacker.BS > 6) {
-		return errors.New("bs must be between 2 and 6 (unless Torrent)")
+	// Save 1+ is impossible.
+	if len(req.Target.Groups) == 0 && req.Target.Save < 2 {
+		return errors.New("save must be 2+ or higher")
+	}
+	if len(req.Weapons) > 0 && req.Rules.SingleReroll != (SingleRerollDTO{}) {
+		return errors.New("rules.single_reroll is not supported with weapons")
+	}
+	if len(req.Target.Groups) > 0 && req.Rules.SingleReroll.Save &&
+		(req.Rules.SingleReroll.Damage || req.Attacker.DamageOptions.Reroll == calculator.DiceRerollSingle) {
+		return errors.New("rules.single_reroll.save cannot be combined with a single damage reroll against target.groups")
 	}
 
-	// Save 1+ is impossible.
-	if req.Target.Save < 2 {
+	return nil
+}
+
+// validateWoundsRemaining checks that wounds_remaining is positive, or that
+// wounds_remaining_dist is a distribution, and, when the unit's size is known,
+// that neither exceeds the unit's wounds.
+func validateWoundsRemaining(target *TargetDTO) error {
+	if target.WoundsRemaining == nil && target.WoundsRemainingDist == nil {
+		return nil
+	}
+	if target.WoundsRemaining != nil && target.WoundsRemainingDist != nil {
+		return errors.New("target.wounds_remaining and target.wounds_remaining_dist cannot both be set")
+	}
+
+	total := 0
+	switch {
+	case len(target.Groups) > 0:
+		for _, g := range target.Groups {
+			total += g.ModelCount * g.WoundsPerModel
+		}
+	case target.ModelCount != nil:
+		total = *target.ModelCount * target.WoundsPerModel
+	}
+
+	if target.WoundsRemaining != nil {
+		left := *target.WoundsRemaining
+		if left <= 0 {
+			return errors.New("target.wounds_remaining must be positive")
+		}
+		if total > 0 && left > total {
+			return fmt.Errorf("target.wounds_remaining %d exceeds the unit's %d wounds", left, total)
+		}
+		return nil
+	}
+
+	// A remaining_wounds result misses the probability pruned from it.
+	const sumTolerance = 1e-6
+	sum := 0.0
+	for left, p := range target.WoundsRemainingDist {
+		if left < 0 {
+			return fmt.Errorf("target.wounds_remaining_dist has negative wounds %d", left)
+		}
+		if total > 0 && left > total {
+			return fmt.Errorf("target.wounds_remaining_dist %d exceeds the unit's %d wounds", left, total)
+		}
+		if p < 0 || math.IsNaN(p) {
+			return fmt.Errorf("target.wounds_remaining_dist[%d] must be a probability, got %g", left, p)
+		}
+		sum += p
+	}
+	if math.Abs(sum-1) > sumTolerance {
+		return fmt.Errorf("target.wounds_remaining_dist must sum to 1, got %g", sum)
+	}
+	return nil
+}
+
+// validatePrecisionTarget checks that precision_target names a character group.
+func validatePrecisionTarget(target *TargetDTO) error {
+	if target.PrecisionTarget == "" {
+		return nil
+	}
+	for _, g := range target.Groups {
+		if g.Character && g.Name == target.PrecisionTarget {
+			return nil
+		}
+	}
+	return errors.New("target.precision_target must name a character group")
+}
+
+// validateModelGroup checks a single group of a mixed target unit.
+func validateModelGroup(g *ModelGroupDTO) error {
+	if g.ModelCount <= 0 {
+		return errors.New("model_count must be positive")
+	}
+	if g.WoundsPerModel <= 0 {
+		return errors.New("wounds_per_model must be positive")
+	}
+	if g.Save < 2 {
 		return errors.New("save must be 2+ or higher")
 	}
+	if g.Invulnerable != nil && (*g.Invulnerable < 2 || *g.Invulnerable > 6) {
+		return errors.New("invulnerable save must be between 2+ and 6+")
+	}
+	if g.FeelNoPain != nil && (*g.FeelNoPain < 2 || *g.FeelNoPain > 6) {
+		return errors.New("fnp must be between 2+ and 6+")
+	}
+	return nil
+}
+
+// validateModifiers checks that every modifier source is named.
+func validateModifiers(lists ...[]ModifierDTO) error {
+	for _, mods := range lists {
+		for _, m := range mods {
+			if strings.TrimSpace(m.Source) == "" {
+				return errors.New("modifier source cannot be empty")
+			}
+		}
+	}
+	return nil
+}
 
+// validateDiceOptions checks that reroll_below comes with the "below"
+// policy and that best_of and minimum are in range.
+func validateDiceOptions(o DiceOptionsDTO) error {
+	if (o.Reroll == calculator.DiceRerollBelow) != (o.RerollBelow != 0) {
+		return errors.New("reroll_below must be set exactly when reroll is \"below\"")
+	}
+	if o.RerollBelow < 0 {
+		return errors.New("reroll_below cannot be negative")
+	}
+	if o.BestOf < 0 || o.BestOf > 6 {
+		return errors.New("best_of must be between 0 and 6")
+	}
+	if o.Minimum < 0 {
+		return errors.New("minimum cannot be negative")
+	}
 	return nil
 }
 
@@ -204,30 +587,23 @@ func (req *DamageRequestDTO) ToDomain() (calculator.CombatSimulationRequest, err
 	critHit := defaultThreshold(req.Rules.CriticalHitThreshold, 6)
 	critWound := defaultThreshold(req.Rules.CriticalWoundThreshold, 6)
 
-	attacks, err := ParseDiceString(req.Attacker.AttacksString)
+	attacker, err := req.Attacker.toDomain()
 	if err != nil {
-		return calculator.CombatSimulationRequest{}, fmt.Errorf("attacker attacks: %w", err)
+		return calculator.CombatSimulationRequest{}, err
 	}
 
-	damage, err := ParseDiceString(req.Attacker.D)
-	if err != nil {
-		return calculator.CombatSimulationRequest{}, fmt.Errorf("attacker damage: %w", err)
+	var weapons []calculator.AttackerProfile
+	for i := range req.Weapons {
+		weapon, err := req.Weapons[i].toDomain()
+		if err != nil {
+			return calculator.CombatSimulationRequest{}, fmt.Errorf("weapons[%d]: %w", i, err)
+		}
+		weapons = append(weapons, weapon)
 	}
 
 	model := calculator.CombatSimulationRequest{
-		Attacker: calculator.AttackerProfile{
-			Count:             req.Attacker.NumModels,
-			Attacks:           attacks,
-			BS:                req.Attacker.BS,
-			Strength:          req.Attacker.S,
-			AP:                req.Attacker.AP,
-			Damage:            damage,
-			SustainedHits:     req.Attacker.SustainedHits,
-			Blast:             req.Attacker.Blast,
-			LethalHits:        req.Attacker.LethalHits,
-			DevastatingWounds: req.Attacker.DevastatingWounds,
-			Torrent:           req.Attacker.Torrent,
-		},
+		Attacker: attacker,
+		Weapons:  weapons,
 		Target: calculator.TargetProfile{
 			Count:          req.Target.ModelCount,
 			Toughness:      req.Target.T,
@@ -236,23 +612,188 @@ func (req *DamageRequestDTO) ToDomain() (calculator.CombatSimulationRequest, err
 			WoundsPerModel: req.Target.WoundsPerModel,
 			FeelNoPain:     req.Target.FeelNoPain,
 			HasCover:       req.Target.Cover,
+			Keywords:       req.Target.Keywords,
+
+			MortalFeelNoPain:              req.Target.MortalFeelNoPain,
+			FeelNoPainExcludesDevastating: req.Target.FeelNoPainExcludesDevastating,
+
+			DamageReduction:                    req.Target.DamageReduction,
+			HalveDamage:                        req.Target.HalveDamage,
+			DamageReductionExcludesDevastating: req.Target.DamageReductionExcludesDevastating,
+
+			Stealth:                   req.Target.Stealth,
+			MinusOneToWoundIfStronger: req.Target.MinusOneToWoundIfStronger,
+			APReduction:               req.Target.APReduction,
+			WoundRollsFailUpTo:        req.Target.WoundRollsFailUpTo,
+
+			Groups:              groupsToDomain(req.Target.Groups),
+			PrecisionTarget:     req.Target.PrecisionTarget,
+			WoundsRemaining:     req.Target.WoundsRemaining,
+			WoundsRemainingDist: req.Target.WoundsRemainingDist,
 		},
 		Settings: calculator.SimulationSettings{
 			HitReroll:              req.Rules.HitReroll,
 			WoundReroll:            req.Rules.WoundReroll,
 			SaveReroll:             req.Rules.SaveReroll,
 			SaveModifier:           req.Rules.SaveModifier,
+			HitModifiers:           modifiersToDomain(req.Rules.HitModifiers),
+			WoundModifiers:         modifiersToDomain(req.Rules.WoundModifiers),
 			CriticalHitThreshold:   critHit,
 			CriticalWoundThreshold: critWound,
-
-			HitModifier:   req.Attacker.HitModifier,
-			WoundModifier: req.Attacker.WoundModifier,
+			HalfRange:              req.Rules.HalfRange,
+			RemainedStationary:     req.Rules.RemainedStationary,
+			Charged:                req.Rules.Charged,
+			TargetNotVisible:       req.Rules.TargetNotVisible,
+			FiringMode:             req.Rules.FiringMode,
+			SingleReroll: calculator.SingleRerolls{
+				Hit:     req.Rules.SingleReroll.Hit,
+				Wound:   req.Rules.SingleReroll.Wound,
+				Save:    req.Rules.SingleReroll.Save,
+				Damage:  req.Rules.SingleReroll.Damage,
+				Attacks: req.Rules.SingleReroll.Attacks,
+			},
+		},
+		Thresholds: calculator.ResultThresholds{
+			Destroyed: req.Thresholds.Destroyed,
+			Damage:    req.Thresholds.Damage,
+		},
+		Points: calculator.UnitPoints{
+			Attacker:       req.Points.Attacker,
+			TargetPerModel: req.Points.TargetPerModel,
 		},
 	}
 
 	return model, nil
 }
 
+// toDomain maps a single weapon profile onto the calculator's model.
+func (a *AttackerDTO) toDomain() (calculator.AttackerProfile, error) {
+	attacks, err := ParseDiceString(a.AttacksString)
+	if err != nil {
+		return calculator.AttackerProfile{}, fmt.Errorf("attacker attacks: %w", err)
+	}
+
+	damage, err := ParseDiceString(a.D)
+	if err != nil {
+		return calculator.AttackerProfile{}, fmt.Errorf("attacker damage: %w", err)
+	}
+
+	var sustainedHitsDice calculator.DiceRoll
+	if a.SustainedHitsDice != "" {
+		sustainedHitsDice, err = ParseDiceString(a.SustainedHitsDice)
+		if err != nil {
+			return calculator.AttackerProfile{}, fmt.Errorf("attacker sustained hits dice: %w", err)
+		}
+	}
+
+	var mortalWounds calculator.DiceRoll
+	if a.MortalWounds != "" {
+		mortalWounds, err = ParseDiceString(a.MortalWounds)
+		if err != nil {
+			return calculator.AttackerProfile{}, fmt.Errorf("attacker mortal wounds: %w", err)
+		}
+	}
+
+	return calculator.AttackerProfile{
+		Count:             a.NumModels,
+		Attacks:           attacks,
+		BS:                a.BS,
+		Strength:          a.S,
+		AP:                a.AP,
+		Damage:            damage,
+		SustainedHits:     a.SustainedHits,
+		Blast:             a.Blast,
+		LethalHits:        a.LethalHits,
+		DevastatingWounds: a.DevastatingWounds,
+		Torrent:           a.Torrent,
+		Anti:              antiToDomain(a.Anti),
+		MeltaX:            a.Melta,
+		RapidFireX:        a.RapidFire,
+		Heavy:             a.Heavy,
+		Lance:             a.Lance,
+		TwinLinked:        a.TwinLinked,
+		IgnoresCover:      a.IgnoresCover,
+		IndirectFire:      a.IndirectFire,
+		HitModifier:       a.HitModifier,
+		WoundModifier:     a.WoundModifier,
+
+		HitModifiers:         modifiersToDomain(a.HitModifiers),
+		WoundModifiers:       modifiersToDomain(a.WoundModifiers),
+		IgnoreHitModifiers:   a.IgnoreHitModifiers,
+		IgnoreWoundModifiers: a.IgnoreWoundModifiers,
+
+		MortalWounds:            mortalWounds,
+		MortalWoundsOn:          a.MortalWoundsOn,
+		LegacyDevastatingWounds: a.LegacyDevastatingWounds,
+		Precision:               a.Precision,
+
+		DamageOptions:        diceOptionsToDomain(a.DamageOptions),
+		AttacksOptions:       diceOptionsToDomain(a.AttacksOptions),
+		AttacksRolledPerUnit: a.AttacksPerUnit,
+
+		SustainedHitsDice:   sustainedHitsDice,
+		CriticalHitStrength: a.CriticalHitStrength,
+		CriticalHitAPBonus:  a.CriticalHitAPBonus,
+
+		CriticalWoundAPBonus:     a.CriticalWoundAPBonus,
+		CriticalWoundDamageBonus: a.CriticalWoundDamageBonus,
+
+		Hazardous:      a.Hazardous,
+		WoundsPerModel: a.WoundsPerModel,
+		Keywords:       a.Keywords,
+	}, nil
+}
+
+func modifiersToDomain(mods []ModifierDTO) []calculator.Modifier {
+	if len(mods) == 0 {
+		return nil
+	}
+	out := make([]calculator.Modifier, len(mods))
+	for i, m := range mods {
+		out[i] = calculator.Modifier{Source: m.Source, Value: m.Value}
+	}
+	return out
+}
+
+func diceOptionsToDomain(o DiceOptionsDTO) calculator.DiceOptions {
+	return calculator.DiceOptions{
+		Reroll:      o.Reroll,
+		RerollBelow: o.RerollBelow,
+		BestOf:      o.BestOf,
+		Minimum:     o.Minimum,
+	}
+}
+
+func antiToDomain(anti []AntiDTO) []calculator.AntiKeyword {
+	if len(anti) == 0 {
+		return nil
+	}
+	out := make([]calculator.AntiKeyword, len(anti))
+	for i, a := range anti {
+		out[i] = calculator.AntiKeyword{Keyword: a.Keyword, Threshold: a.Threshold}
+	}
+	return out
+}
+
+func groupsToDomain(groups []ModelGroupDTO) []calculator.ModelGroup {
+	if len(groups) == 0 {
+		return nil
+	}
+	out := make([]calculator.ModelGroup, len(groups))
+	for i, g := range groups {
+		out[i] = calculator.ModelGroup{
+			Name:           g.Name,
+			Count:          g.ModelCount,
+			WoundsPerModel: g.WoundsPerModel,
+			Save:           g.Save,
+			Invulnerable:   g.Invulnerable,
+			FeelNoPain:     g.FeelNoPain,
+			Character:      g.Character,
+		}
+	}
+	return out
+}
+
 func defaultThreshold(v, fallback int) int {
 	if v == 0 {
 		return fallback
@@ -263,6 +804,9 @@ func defaultThreshold(v, fallback int) int {
 type DamageResponseDTO struct {
 	Summary       SummaryDTO       `json:"summary"`
 	Distributions DistributionsDTO `json:"distributions"`
+	// Weapons breaks a multi-weapon volley down per profile (attacker first),
+	// each as if it had fired alone at the undamaged target.
+	Weapons []WeaponResultDTO `json:"weapons,omitempty"`
 
 	Message     string `json:"message"`
 	RequestUUID string `json:"request_uuid,omitempty"`
@@ -271,6 +815,49 @@ type DamageResponseDTO struct {
 type SummaryDTO struct {
 	AverageHits      float64 `json:"average_hits"`
 	AverageDestroyed float64 `json:"average_destroyed"`
+	// CharacterDestroyed is the probability that the precision_target character group is destroyed.
+	CharacterDestroyed float64 `json:"character_destroyed,omitempty"`
+	// EffectiveHitModifier and EffectiveWoundModifier are the net, capped modifiers the profile rolled with.
+	EffectiveHitModifier   int `json:"effective_hit_modifier"`
+	EffectiveWoundModifier int `json:"effective_wound_modifier"`
+	// Stats summarizes each distribution, keyed like distributions.
+	Stats DistributionStatsDTO `json:"stats"`
+	// WipeProbability is the probability that every model of the target is destroyed.
+	WipeProbability float64 `json:"wipe_probability"`
+	// DestroyedAtLeast and DamageAtLeast map each requested threshold to the probability of reaching it.
+	DestroyedAtLeast map[int]float64 `json:"models_destroyed_at_least,omitempty"`
+	DamageAtLeast    map[int]float64 `json:"damage_at_least,omitempty"`
+	// DestroyedTailMass and DamageTailMass are the probability dropped by pruning, by which
+	// the probabilities above may be low.
+	DestroyedTailMass float64 `json:"models_destroyed_tail_mass"`
+	DamageTailMass    float64 `json:"damage_tail_mass"`
+	// ExpectedPointsDestroyed is the mean of points_destroyed, and PointsPer100 that mean
+	// per 100 points of the attacking unit. Zero when the points are not given.
+	ExpectedPointsDestroyed float64 `json:"expected_points_destroyed"`
+	PointsPer100            float64 `json:"points_destroyed_per_100"`
+}
+
+// DistributionStatsDTO holds the summary statistics of each distribution.
+type DistributionStatsDTO struct {
+	Hits      StatsDTO `json:"hits"`
+	Wounds    StatsDTO `json:"wounds"`
+	Saves     StatsDTO `json:"saves_failed"`
+	Damage    StatsDTO `json:"damage"`
+	Destroyed StatsDTO `json:"models_destroyed"`
+}
+
+// StatsDTO summarizes one distribution.
+type StatsDTO struct {
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	StdDev   float64 `json:"std_dev"`
+	// Median, P10 and P90 are the smallest values reached with 50%, 10% and 90% cumulative probability.
+	Median int `json:"median"`
+	P10    int `json:"p10"`
+	P90    int `json:"p90"`
+	Mode   int `json:"mode"`
+	// AtLeast maps each k to the probability of at least k.
+	AtLeast map[int]float64 `json:"at_least"`
 }
 
 type DistributionsDTO struct {
@@ -279,22 +866,113 @@ type DistributionsDTO struct {
 	Saves     map[int]float64 `json:"saves_failed"`
 	Damage    map[int]float64 `json:"damage"`
 	Destroyed map[int]float64 `json:"models_destroyed"`
+	// WoundsLost is damage without the damage in excess of each model's wounds.
+	WoundsLost map[int]float64 `json:"wounds_lost"`
+	// MortalWounds is the number of mortal wounds inflicted, before FNP.
+	MortalWounds map[int]float64 `json:"mortal_wounds"`
+	// GroupsDestroyed is models_destroyed per target group, in request order.
+	GroupsDestroyed []GroupDestroyedDTO `json:"groups_destroyed,omitempty"`
+	// SelfDestroyed is the number of attacking models destroyed by their Hazardous tests.
+	SelfDestroyed map[int]float64 `json:"self_destroyed"`
+	// RemainingWounds is the total wounds the target has left; WoundedModel is the wounds left
+	// on the model the next attack would be allocated to (0 once the unit is destroyed).
+	RemainingWounds map[int]float64 `json:"remaining_wounds"`
+	WoundedModel    map[int]float64 `json:"wounded_model_wounds"`
+	// PointsDestroyed is models_destroyed priced at points.target_per_model.
+	PointsDestroyed map[int]float64 `json:"points_destroyed,omitempty"`
+}
+
+// GroupDestroyedDTO is the models_destroyed distribution of one target group.
+type GroupDestroyedDTO struct {
+	Name      string          `json:"name,omitempty"`
+	Destroyed map[int]float64 `json:"models_destroyed"`
+}
+
+// WeaponResultDTO is one weapon profile's share of a multi-weapon volley.
+type WeaponResultDTO struct {
+	Summary       SummaryDTO       `json:"summary"`
+	Distributions DistributionsDTO `json:"distributions"`
 }
 
 func MapResultToResponse(res calculator.SimulationResult, uuid string) DamageResponseDTO {
+	var weapons []WeaponResultDTO
+	for _, w := range res.Weapons {
+		weapons = append(weapons, WeaponResultDTO{
+			Summary:       mapSummary(w),
+			Distributions: mapDistributions(w),
+		})
+	}
+
 	return DamageResponseDTO{
-		Summary: SummaryDTO{
-			AverageHits:      res.AverageHits,
-			AverageDestroyed: res.AverageDestroyed,
-		},
-		Distributions: DistributionsDTO{
-			Hits:      res.HitDist,
-			Wounds:    res.WoundDist,
-			Saves:     res.PenDist,
-			Damage:    res.DamageDist,
-			Destroyed: res.DestroyedDist,
+		Summary:       mapSummary(res),
+		Distributions: mapDistributions(res),
+		Weapons:       weapons,
+		Message:       "Calculation successful",
+		RequestUUID:   uuid,
+	}
+}
+
+func mapSummary(res calculator.SimulationResult) SummaryDTO {
+	return SummaryDTO{
+		AverageHits:      res.AverageHits,
+		AverageDestroyed: res.AverageDestroyed,
+
+		CharacterDestroyed: res.CharacterDestroyedProb,
+
+		EffectiveHitModifier:   res.EffectiveHitModifier,
+		EffectiveWoundModifier: res.EffectiveWoundModifier,
+
+		Stats: DistributionStatsDTO{
+			Hits:      mapStats(res.Stats.Hits),
+			Wounds:    mapStats(res.Stats.Wounds),
+			Saves:     mapStats(res.Stats.Pens),
+			Damage:    mapStats(res.Stats.Damage),
+			Destroyed: mapStats(res.Stats.Destroyed),
 		},
-		Message:     "Calculation successful",
-		RequestUUID: uuid,
+
+		WipeProbability:   res.WipeProb,
+		DestroyedAtLeast:  res.DestroyedAtLeast,
+		DamageAtLeast:     res.DamageAtLeast,
+		DestroyedTailMass: res.DestroyedTailMass,
+		DamageTailMass:    res.DamageTailMass,
+
+		ExpectedPointsDestroyed: res.ExpectedPointsDestroyed,
+		PointsPer100:            res.PointsPer100,
+	}
+}
+
+func mapStats(s calculator.DistStats) StatsDTO {
+	return StatsDTO{
+		Mean:     s.Mean,
+		Variance: s.Variance,
+		StdDev:   s.StdDev,
+		Median:   s.Median,
+		P10:      s.P10,
+		P90:      s.P90,
+		Mode:     s.Mode,
+		AtLeast:  s.AtLeast,
+	}
+}
+
+func mapDistributions(res calculator.SimulationResult) DistributionsDTO {
+	var groups []GroupDestroyedDTO
+	for _, g := range res.Groups {
+		groups = append(groups, GroupDestroyedDTO{Name: g.Name, Destroyed: g.DestroyedDist})
+	}
+
+	return DistributionsDTO{
+		Hits:      res.HitDist,
+		Wounds:    res.WoundDist,
+		Saves:     res.PenDist,
+		Damage:    res.DamageDist,
+		Destroyed: res.DestroyedDist,
+
+		WoundsLost:      res.WoundsLostDist,
+		MortalWounds:    res.MortalWoundDist,
+		GroupsDestroyed: groups,
+		SelfDestroyed:   res.SelfDestroyedDist,
+		RemainingWounds: res.RemainingWoundsDist,
+		WoundedModel:    res.WoundedModelDist,
+		PointsDestroyed: res.PointsDestroyedDist,
 	}
 }
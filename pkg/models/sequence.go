@@ -0,0 +1,129 @@
+// Copyright (c) 2025 Olbutov Aleksandr
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package damagerequest
+
+import (
+	"errors"
+	"fmt"
+
+	calculator "github.com/AnNoName1/warhammer40k10thCalc/internal/calculator"
+)
+
+// SequenceRequestDTO is several units shooting the same target one after
+// another, each at whatever the previous ones left alive.
+type SequenceRequestDTO struct {
+	Activations []ActivationDTO `json:"activations"`
+	Target      TargetDTO       `json:"target"`
+}
+
+// ActivationDTO is one unit's attack in a sequence: the attacker, weapons
+// and rules of a DamageRequestDTO, without rules.single_reroll.
+type ActivationDTO struct {
+	Attacker AttackerDTO   `json:"attacker"`
+	Weapons  []AttackerDTO `json:"weapons,omitempty"`
+	Rules    RulesDTO      `json:"rules"`
+}
+
+// request pairs an activation with the sequence's target, so it can be
+// validated and mapped like a single damage request.
+func (a ActivationDTO) request(target TargetDTO) DamageRequestDTO {
+	return DamageRequestDTO{
+		Attacker: a.Attacker,
+		Weapons:  a.Weapons,
+		Target:   target,
+		Rules:    a.Rules,
+	}
+}
+
+func (req *SequenceRequestDTO) Validate() error {
+	if len(req.Activations) == 0 {
+		return errors.New("activations cannot be empty")
+	}
+	for i, a := range req.Activations {
+		single := a.request(req.Target)
+		if err := single.Validate(); err != nil {
+			return fmt.Errorf("activations[%d]: %w", i, err)
+		}
+		if a.Rules.SingleReroll != (SingleRerollDTO{}) {
+			return fmt.Errorf("activations[%d]: rules.single_reroll is not supported in a sequence", i)
+		}
+	}
+	return nil
+}
+
+func (req *SequenceRequestDTO) ToDomain() (calculator.SequenceRequest, error) {
+	var model calculator.SequenceRequest
+	for i, a := range req.Activations {
+		single := a.request(req.Target)
+		domain, err := single.ToDomain()
+		if err != nil {
+			return calculator.SequenceRequest{}, fmt.Errorf("activations[%d]: %w", i, err)
+		}
+		model.Target = domain.Target
+		model.Activations = append(model.Activations, calculator.Activation{
+			Attacker: domain.Attacker,
+			Weapons:  domain.Weapons,
+			Settings: domain.Settings,
+		})
+	}
+	return model, nil
+}
+
+type SequenceResponseDTO struct {
+	// Steps has one entry per activation run. Activations after the target is
+	// certain to be wiped are skipped, so it may be shorter than the request.
+	Steps []SequenceStepDTO `json:"steps"`
+	// Destroyed and WipeProbability are those of the last step.
+	Destroyed       map[int]float64 `json:"models_destroyed"`
+	WipeProbability float64         `json:"wipe_probability"`
+
+	Message     string `json:"message"`
+	RequestUUID string `json:"request_uuid,omitempty"`
+}
+
+// SequenceStepDTO is the outcome of one activation of a sequence.
+type SequenceStepDTO struct {
+	// Destroyed is the models this activation destroyed; CumulativeDestroyed those destroyed since the first.
+	Destroyed           map[int]float64 `json:"models_destroyed"`
+	CumulativeDestroyed map[int]float64 `json:"cumulative_models_destroyed"`
+	WipeProbability     float64         `json:"wipe_probability"`
+	RemainingWounds     map[int]float64 `json:"remaining_wounds"`
+}
+
+func MapSequenceToResponse(res calculator.SequenceResult, uuid string) SequenceResponseDTO {
+	steps := make([]SequenceStepDTO, 0, len(res.Steps))
+	for _, s := range res.Steps {
+		steps = append(steps, SequenceStepDTO{
+			Destroyed:           s.DestroyedDist,
+			CumulativeDestroyed: s.CumulativeDestroyedDist,
+			WipeProbability:     s.WipeProb,
+			RemainingWounds:     s.RemainingWoundsDist,
+		})
+	}
+
+	return SequenceResponseDTO{
+		Steps:           steps,
+		Destroyed:       res.DestroyedDist,
+		WipeProbability: res.WipeProb,
+		Message:         "Calculation successful",
+		RequestUUID:     uuid,
+	}
+}